@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+
+	"github.com/muesli/termenv"
+)
+
+// When ijq uses colors, as set with --color.
+const (
+	_colorAuto   = "auto"
+	_colorAlways = "always"
+	_colorNever  = "never"
+)
+
+var _colorModes = []string{_colorAuto, _colorAlways, _colorNever}
+
+// colorProfile returns the colors to draw with on out. In auto mode they
+// are those the terminal supports, as told by TERM and COLORTERM, and none
+// if NO_COLOR is set or the terminal is dumb. always uses at least the 16
+// ANSI colors, and never none at all.
+func colorProfile(mode string, out io.Writer) termenv.Profile {
+	o := termenv.NewOutput(out)
+	switch mode {
+	case _colorAlways:
+		// Profiles are ordered from the most colors to none.
+		return min(o.ColorProfile(), termenv.ANSI)
+	case _colorNever:
+		return termenv.Ascii
+	}
+	return o.EnvColorProfile()
+}
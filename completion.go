@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/maolonglong/ijq/internal/input"
+	"github.com/maolonglong/ijq/tui"
+)
+
+// _shells are the shells that ijq completion writes scripts for.
+var _shells = []string{"bash", "zsh", "fish"}
+
+// completionFlag describes a flag for a completion script.
+type completionFlag struct {
+	name  string
+	usage string
+	// value is set for flags that take a value, which is one of choices
+	// if there are any and a file name otherwise.
+	value   bool
+	choices []string
+}
+
+// completionFlags returns the flags, including --arg and the others that
+// take two values and so are not defined with package flag.
+func completionFlags() []completionFlag {
+	choices := map[string][]string{
+		"color":       _colorModes,
+		"engine":      {"jq", "gojq", "jaq"},
+		"input":       input.Formats,
+		"output":      {input.FormatJSON, input.FormatYAML},
+		"output-mode": tui.OutputModes,
+		"scroll":      tui.ScrollModes,
+		"keys":        tui.KeyPresets,
+		"theme":       tui.ThemeNames,
+	}
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:    f.Name,
+			usage:   usage,
+			value:   !ok || !b.IsBoolFlag(),
+			choices: choices[f.Name],
+		})
+	})
+	return append(flags,
+		completionFlag{name: "arg", usage: "bind $name to the string value", value: true, choices: []string{}},
+		completionFlag{name: "argjson", usage: "bind $name to the JSON text", value: true, choices: []string{}},
+		completionFlag{name: "rawfile", usage: "bind $name to the contents of the file", value: true, choices: []string{}},
+		completionFlag{name: "slurpfile", usage: "bind $name to an array of the JSON texts in the file", value: true, choices: []string{}},
+	)
+}
+
+// dashes returns the flag as it is written on the command line: single
+// letters with one dash and the rest with two.
+func (f completionFlag) dashes() string {
+	if len(f.name) == 1 {
+		return "-" + f.name
+	}
+	return "--" + f.name
+}
+
+// writeCompletion writes the completion script for the shell named by
+// args, as in ijq completion bash.
+func writeCompletion(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: ijq completion %s", strings.Join(_shells, "|"))
+	}
+	flags := completionFlags()
+	switch args[0] {
+	case "bash":
+		return bashCompletion(w, flags)
+	case "zsh":
+		return zshCompletion(w, flags)
+	case "fish":
+		return fishCompletion(w, flags)
+	default:
+		return fmt.Errorf("unknown shell %q: must be one of %s", args[0], strings.Join(_shells, ", "))
+	}
+}
+
+func bashCompletion(w io.Writer, flags []completionFlag) error {
+	var all, files []string
+	var cases strings.Builder
+	for _, f := range flags {
+		all = append(all, f.dashes())
+		switch {
+		case !f.value:
+		case f.choices == nil:
+			files = append(files, f.dashes())
+		default:
+			fmt.Fprintf(&cases, "\t%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\t\t;;\n",
+				f.dashes(), strings.Join(f.choices, " "))
+		}
+	}
+	_, err := fmt.Fprintf(w, `# bash completion for ijq; load with: source <(ijq completion bash)
+_ijq() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	if [[ $COMP_CWORD -eq 2 && $prev == completion ]]; then
+		COMPREPLY=($(compgen -W %q -- "$cur"))
+		return
+	fi
+	case $prev in
+%s	%s)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return
+		;;
+	esac
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W %q -- "$cur"))
+		return
+	fi
+	COMPREPLY=($(compgen -f -- "$cur"))
+}
+complete -o filenames -F _ijq ijq
+`, strings.Join(_shells, " "), cases.String(), strings.Join(files, "|"), strings.Join(all, " "))
+	return err
+}
+
+func zshCompletion(w io.Writer, flags []completionFlag) error {
+	escape := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+	var specs strings.Builder
+	for _, f := range flags {
+		spec := fmt.Sprintf("%s[%s]", f.dashes(), escape.Replace(f.usage))
+		switch {
+		case !f.value:
+		case f.choices == nil:
+			spec = fmt.Sprintf("%s=[%s]:file:_files", f.dashes(), escape.Replace(f.usage))
+		default:
+			spec = fmt.Sprintf("%s=[%s]:value:(%s)", f.dashes(), escape.Replace(f.usage), strings.Join(f.choices, " "))
+		}
+		fmt.Fprintf(&specs, "\t\t'%s' \\\n", spec)
+	}
+	_, err := fmt.Fprintf(w, `#compdef ijq
+# zsh completion for ijq; load with: source <(ijq completion zsh)
+_ijq() {
+	if (( CURRENT == 3 )) && [[ $words[2] == completion ]]; then
+		_values shell %s
+		return
+	fi
+	_arguments \
+%s		'*:file:_files'
+}
+compdef _ijq ijq
+`, strings.Join(_shells, " "), specs.String())
+	return err
+}
+
+func fishCompletion(w io.Writer, flags []completionFlag) error {
+	escape := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+	var sb strings.Builder
+	sb.WriteString("# fish completion for ijq; load with: ijq completion fish | source\n")
+	fmt.Fprintf(&sb, "complete -c ijq -n '__fish_is_first_arg' -a completion -d 'write a shell completion script'\n")
+	fmt.Fprintf(&sb, "complete -c ijq -n '__fish_seen_subcommand_from completion' -f -a '%s'\n", strings.Join(_shells, " "))
+	for _, f := range flags {
+		opt := "-l " + f.name
+		if len(f.name) == 1 {
+			opt = "-s " + f.name
+		}
+		fmt.Fprintf(&sb, "complete -c ijq %s -d '%s'", opt, escape.Replace(f.usage))
+		switch {
+		case !f.value:
+		case f.choices == nil:
+			sb.WriteString(" -r -F")
+		default:
+			fmt.Fprintf(&sb, " -x -a '%s'", strings.Join(f.choices, " "))
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
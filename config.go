@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configPath returns the config file in $XDG_CONFIG_HOME/ijq, which
+// defaults to ~/.config.
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ijq", "config.toml"), nil
+}
+
+// flagKey identifies the variable behind a flag, which aliases such as -r
+// and --raw-output share.
+func flagKey(f *flag.Flag) string {
+	return fmt.Sprintf("%p", f.Value)
+}
+
+// envName returns the environment variable for flag name, such as
+// IJQ_OUTPUT_MODE for --output-mode.
+func envName(name string) string {
+	return "IJQ_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// _envAliases are shorter names of the environment variables of some
+// flags.
+var _envAliases = map[string]string{
+	"jq-path": "IJQ_JQ",
+}
+
+// _envFlags are the flags that environment variables may set: display and
+// behavior options, but not those that act once, such as --version, --exec
+// or --session, or change what a filter reads, such as --slurp, which a
+// variable left in a shell would silently apply to every run.
+var _envFlags = []string{
+	"keys", "config", "engine", "jq-path", "no-history", "history-size",
+	"no-mouse", "no-scrollbar", "output-mode", "output", "xml-attr-prefix",
+	"xml-text-key", "http-timeout", "no-validate", "timeout", "live",
+	"max-lines", "debounce", "split-ratio", "wrap", "redact", "redact-keys",
+	"theme", "color", "scroll", "line-numbers", "raw-output",
+	"compact-output", "sort-keys", "tab", "indent", "ascii-output",
+}
+
+// loadEnv applies the IJQ_* environment variables of _envFlags to the
+// flags that set does not have yet, and adds them to it.
+func loadEnv(set map[string]bool) error {
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if err != nil || !slices.Contains(_envFlags, f.Name) || set[flagKey(f)] {
+			return
+		}
+		v, ok := os.LookupEnv(envName(f.Name))
+		if alias := _envAliases[f.Name]; !ok && alias != "" {
+			v, ok = os.LookupEnv(alias)
+		}
+		if !ok {
+			return
+		}
+		if e := f.Value.Set(v); e != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", v, envName(f.Name), e)
+			return
+		}
+		set[flagKey(f)] = true
+	})
+	return err
+}
+
+// loadConfig applies the config file at path as defaults for the flags:
+// every key names a flag, such as engine = "gojq" or raw-output = true,
+// and sets it unless set has it from the command line or environment.
+// The [keys] table is returned as the keys to bind each action to, for
+// tui.KeyMap.Remap, and the [theme] table as the colors to change in the
+// theme. A missing file is only an error if it was asked for with
+// --config.
+func loadConfig(path string, explicit bool, set map[string]bool) (keys map[string][]string, colors map[string]string, err error) {
+	var cfg map[string]any
+	_, err = toml.DecodeFile(path, &cfg)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %w", err)
+	}
+	for name, v := range cfg {
+		// keys is both the --keys preset and the table of key bindings,
+		// which takes the preset as preset = "vim".
+		if table, ok := v.(map[string]any); ok && name == "keys" {
+			v, ok = table["preset"]
+			delete(table, "preset")
+			if keys, err = keyTable(table); err != nil {
+				return nil, nil, fmt.Errorf("config %s: %w", path, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		// Likewise, theme is the --theme to start from and the table of
+		// the colors to change in it, which takes the theme as name =
+		// "dark".
+		if table, ok := v.(map[string]any); ok && name == "theme" {
+			v, ok = table["name"]
+			delete(table, "name")
+			if colors, err = themeTable(table); err != nil {
+				return nil, nil, fmt.Errorf("config %s: %w", path, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		f := flag.Lookup(name)
+		if f == nil || name == "config" {
+			return nil, nil, fmt.Errorf("config %s: unknown key %q", path, name)
+		}
+		if set[flagKey(f)] {
+			continue
+		}
+		values, ok := v.([]any)
+		if !ok {
+			values = []any{v}
+		}
+		for _, v := range values {
+			if _, ok := v.(map[string]any); ok {
+				return nil, nil, fmt.Errorf("config %s: %s: want a value, not a table", path, name)
+			}
+			if err := f.Value.Set(fmt.Sprint(v)); err != nil {
+				return nil, nil, fmt.Errorf("config %s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return keys, colors, nil
+}
+
+// keyTable reads the [keys] table, in which every action takes a key or
+// a list of them, such as quit = ["ctrl+q", "esc"].
+func keyTable(table map[string]any) (map[string][]string, error) {
+	keys := make(map[string][]string, len(table))
+	for name, v := range table {
+		values, ok := v.([]any)
+		if !ok {
+			values = []any{v}
+		}
+		keys[name] = []string{}
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys: %s: want a key name, not %v", name, v)
+			}
+			keys[name] = append(keys[name], s)
+		}
+	}
+	return keys, nil
+}
+
+// themeTable reads the [theme] table, in which every part of the theme
+// takes a color, such as status = "8".
+func themeTable(table map[string]any) (map[string]string, error) {
+	colors := make(map[string]string, len(table))
+	for part, v := range table {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("theme: %s: want a color, not %v", part, v)
+		}
+		colors[part] = s
+	}
+	return colors, nil
+}
+
+// saveConfig sets key name to value, written as TOML, in the config file
+// at path, creating the file if needed. Other lines, comments included, are
+// left alone: the key is replaced where it is, or added before the first
+// table, as top-level keys must be.
+func saveConfig(path, name, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	line := name + " = " + value
+	at := len(lines)
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "[") {
+			at = i
+			break
+		}
+		if k, _, ok := strings.Cut(l, "="); ok && strings.TrimSpace(k) == name {
+			lines[i] = line
+			at = -1
+			break
+		}
+	}
+	if at >= 0 {
+		for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+		lines = slices.Insert(lines, at, line)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
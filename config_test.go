@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testFlags replaces the command-line flags, for the duration of the test,
+// with a few of ijq's kinds: an aliased bool, a string, an int and a
+// repeatable flag.
+type testFlags struct {
+	raw    bool
+	engine string
+	indent int
+	keys   string
+	theme  string
+	lib    []string
+}
+
+func newTestFlags(t *testing.T) *testFlags {
+	t.Helper()
+	saved := flag.CommandLine
+	t.Cleanup(func() { flag.CommandLine = saved })
+	flag.CommandLine = flag.NewFlagSet("ijq", flag.ContinueOnError)
+	f := &testFlags{}
+	flag.BoolVar(&f.raw, "r", false, "")
+	flag.BoolVar(&f.raw, "raw-output", false, "")
+	flag.StringVar(&f.engine, "engine", "", "")
+	flag.IntVar(&f.indent, "indent", 2, "")
+	flag.StringVar(&f.keys, "keys", "default", "")
+	flag.StringVar(&f.theme, "theme", "default", "")
+	flag.String("config", "", "")
+	flag.Func("L", "", func(s string) error {
+		f.lib = append(f.lib, s)
+		return nil
+	})
+	return f
+}
+
+// writeConfig writes a config file with content and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		set        []string
+		want       testFlags
+		wantKeys   map[string][]string
+		wantColors map[string]string
+	}{
+		{"values", "engine = \"gojq\"\nraw-output = true\nindent = 4\n", nil,
+			testFlags{raw: true, engine: "gojq", indent: 4, keys: "default", theme: "default"}, nil, nil},
+		{"short name", "r = true\n", nil,
+			testFlags{raw: true, indent: 2, keys: "default", theme: "default"}, nil, nil},
+		{"set elsewhere", "engine = \"gojq\"\nraw-output = true\n", []string{"engine", "r"},
+			testFlags{indent: 2, keys: "default", theme: "default"}, nil, nil},
+		{"repeatable", "L = [\"a\", \"b\"]\n", nil,
+			testFlags{indent: 2, keys: "default", theme: "default", lib: []string{"a", "b"}}, nil, nil},
+		{"keys preset", "keys = \"vim\"\n", nil,
+			testFlags{indent: 2, keys: "vim", theme: "default"}, nil, nil},
+		{"keys table", "[keys]\npreset = \"vim\"\nquit = [\"ctrl+q\", \"esc\"]\nhelp = \"?\"\nnone = []\n", nil,
+			testFlags{indent: 2, keys: "vim", theme: "default"},
+			map[string][]string{"quit": {"ctrl+q", "esc"}, "help": {"?"}, "none": {}}, nil},
+		{"keys table without preset", "[keys]\nquit = \"ctrl+q\"\n", nil,
+			testFlags{indent: 2, keys: "default", theme: "default"}, map[string][]string{"quit": {"ctrl+q"}}, nil},
+		{"theme table", "[theme]\nname = \"dark\"\nstatus = \"8\"\n", nil,
+			testFlags{indent: 2, keys: "default", theme: "dark"}, nil, map[string]string{"status": "8"}},
+		{"empty", "# nothing\n", nil, testFlags{indent: 2, keys: "default", theme: "default"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFlags(t)
+			set := map[string]bool{}
+			for _, name := range tt.set {
+				set[flagKey(flag.Lookup(name))] = true
+			}
+			keys, colors, err := loadConfig(writeConfig(t, tt.config), true, set)
+			if err != nil {
+				t.Fatalf("loadConfig(%q): %v", tt.config, err)
+			}
+			if !reflect.DeepEqual(*f, tt.want) {
+				t.Errorf("loadConfig(%q) set flags %+v, want %+v", tt.config, *f, tt.want)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("loadConfig(%q) keys = %v, want %v", tt.config, keys, tt.wantKeys)
+			}
+			if !reflect.DeepEqual(colors, tt.wantColors) {
+				t.Errorf("loadConfig(%q) colors = %v, want %v", tt.config, colors, tt.wantColors)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"unknown key", "nope = 1\n", `unknown key "nope"`},
+		{"config key", "config = \"other.toml\"\n", `unknown key "config"`},
+		{"table for a flag", "[engine]\nname = \"jq\"\n", "engine: want a value, not a table"},
+		{"invalid value", "indent = \"wide\"\n", "indent: parse error"},
+		{"key not a string", "[keys]\nquit = 1\n", "keys: quit: want a key name, not 1"},
+		{"color not a string", "[theme]\nstatus = 8\n", "theme: status: want a color, not 8"},
+		{"syntax", "engine = \n", "config: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestFlags(t)
+			_, _, err := loadConfig(writeConfig(t, tt.config), true, map[string]bool{})
+			if err == nil {
+				t.Fatalf("loadConfig(%q) succeeded, want an error", tt.config)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadConfig(%q) error %q, want it to contain %q", tt.config, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	newTestFlags(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if _, _, err := loadConfig(path, false, map[string]bool{}); err != nil {
+		t.Errorf("loading the missing default config: %v", err)
+	}
+	if _, _, err := loadConfig(path, true, map[string]bool{}); err == nil {
+		t.Error("loading a missing --config succeeded, want an error")
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"new file", "", "split-ratio = 0.4\n"},
+		{"replaced in place", "# mine\nsplit-ratio = 0.5 # old\nengine = \"jq\"\n",
+			"# mine\nsplit-ratio = 0.4\nengine = \"jq\"\n"},
+		{"replaced without spaces", "split-ratio=0.5\n", "split-ratio = 0.4\n"},
+		{"added at the end", "engine = \"jq\"\n", "engine = \"jq\"\nsplit-ratio = 0.4\n"},
+		{"added before tables", "engine = \"jq\"\n\n[keys]\nquit = \"q\"\n",
+			"engine = \"jq\"\nsplit-ratio = 0.4\n\n[keys]\nquit = \"q\"\n"},
+		{"table only", "[theme]\nsplit-ratio = \"1\"\n", "split-ratio = 0.4\n[theme]\nsplit-ratio = \"1\"\n"},
+		{"longer key kept", "split-ratio-x = 1\n", "split-ratio-x = 1\nsplit-ratio = 0.4\n"},
+		{"no final newline", "engine = \"jq\"", "engine = \"jq\"\nsplit-ratio = 0.4\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ijq", "config.toml")
+			if tt.config != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := saveConfig(path, "split-ratio", "0.4"); err != nil {
+				t.Fatalf("saveConfig: %v", err)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tt.want {
+				t.Errorf("saveConfig to %q wrote %q, want %q", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSaveConfigReadBack checks that a saved key loads as the flag's value.
+func TestSaveConfigReadBack(t *testing.T) {
+	f := newTestFlags(t)
+	path := writeConfig(t, "# defaults\n[keys]\nquit = \"q\"\n")
+	if err := saveConfig(path, "indent", "4"); err != nil {
+		t.Fatal(err)
+	}
+	keys, _, err := loadConfig(path, true, map[string]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.indent != 4 || !reflect.DeepEqual(keys, map[string][]string{"quit": {"q"}}) {
+		t.Errorf("after saving indent = 4, loaded indent %d and keys %v", f.indent, keys)
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"sync"
+)
+
+// _spillSize is the input size from which the jq engine reads the input
+// from a temporary file, written once, instead of piping it in on every
+// evaluation.
+const _spillSize = 16 << 20
+
+// MaxOutput bounds how much of jq's output is kept; the rest is dropped.
+const MaxOutput = 64 << 20
+
+// OutputBuffer collects jq's output up to limit bytes, or all of it if
+// limit is 0. It can be read while jq is still writing.
+type OutputBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	Limit int
+	total int
+}
+
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total += len(p)
+	n := len(p)
+	if b.Limit > 0 {
+		n = min(n, max(b.Limit-len(b.buf), 0))
+	}
+	b.buf = append(b.buf, p[:n]...)
+	return len(p), nil
+}
+
+// Len returns the number of bytes kept so far.
+func (b *OutputBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buf)
+}
+
+// Text returns what was written so far, cut after the last full line
+// once the limit is reached.
+func (b *OutputBuffer) Text() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.total > len(b.buf) {
+		return string(b.buf[:bytes.LastIndexByte(b.buf, '\n')+1])
+	}
+	return string(b.buf)
+}
+
+// dropped returns the number of bytes written beyond the limit.
+func (b *OutputBuffer) dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total - len(b.buf)
+}
+
+// spillFile keeps a large input in a temporary file for jq to read.
+type spillFile struct {
+	mu      sync.Mutex
+	content string
+	path    string
+}
+
+// file returns the path of a temporary file holding content, writing it
+// unless it holds content already.
+func (s *spillFile) file(content string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Comparing is cheap as long as the input is the same string.
+	if s.path != "" && s.content == content {
+		return s.path, nil
+	}
+	s.remove()
+	f, err := os.CreateTemp("", "ijq-input-*.json")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	s.path, s.content = f.Name(), content
+	return s.path, nil
+}
+
+func (s *spillFile) remove() {
+	if s.path != "" {
+		os.Remove(s.path)
+		s.path, s.content = "", ""
+	}
+}
+
+func (s *spillFile) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove()
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// dialect describes how a jq-compatible binary differs from jq in the
+// flags ijq passes to it.
+type dialect struct {
+	name string
+	// unsupported are the flags of jq that it lacks, which ijq refuses to
+	// evaluate with rather than have it fail on every keystroke.
+	unsupported []string
+	// implied are the flags of jq that it behaves as if always given, and
+	// that ijq leaves out.
+	implied []string
+	// colors returns the environment variable that sets its colors to
+	// those given in the format of JQ_COLORS.
+	colors func(jqColors string) string
+}
+
+// _dialects are the known jq-compatible binaries, by the first word of
+// their --version.
+var _dialects = map[string]dialect{
+	"jq": {
+		name:   "jq",
+		colors: func(c string) string { return "JQ_COLORS=" + c },
+	},
+	"jaq": {
+		name:        "jaq",
+		unsupported: []string{"--stream", "--seq", "--ascii-output"},
+		colors:      func(c string) string { return "JQ_COLORS=" + c },
+	},
+	"gojq": {
+		name:        "gojq",
+		unsupported: []string{"--seq", "--ascii-output"},
+		// gojq keeps objects in maps and so always sorts their keys.
+		implied: []string{"--sort-keys"},
+		colors: func(c string) string {
+			// GOJQ_COLORS puts the object keys before the arrays and objects.
+			f := strings.Split(c, ":")
+			return "GOJQ_COLORS=" + strings.Join([]string{f[0], f[1], f[2], f[3], f[4], f[7], f[5], f[6]}, ":")
+		},
+	},
+}
+
+// detectDialect tells the dialect of a binary from what its --version
+// printed, such as "jaq 2.1.0" or "gojq 0.12.16 (rev: ...)", or else its
+// file name, taking jq for anything unknown.
+func detectDialect(path, version string) dialect {
+	for _, s := range []string{version, filepath.Base(path)} {
+		word, _, _ := strings.Cut(s, " ")
+		word, _, _ = strings.Cut(word, "-")
+		if d, ok := _dialects[word]; ok {
+			return d
+		}
+	}
+	return _dialects["jq"]
+}
+
+// translate adapts the jq flags to d, or reports the first it lacks.
+func (d dialect) translate(flags []string) ([]string, error) {
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		switch {
+		case slices.Contains(d.unsupported, f):
+			return nil, fmt.Errorf("%s does not support %s", d.name, f)
+		case slices.Contains(d.implied, f):
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
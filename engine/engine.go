@@ -0,0 +1,285 @@
+// Package engine evaluates jq filters, with jq, gojq or a jq-compatible
+// binary, and colors their JSON output.
+package engine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Engine evaluates jq filters against the input document.
+type Engine interface {
+	Eval(ctx context.Context, content, filter string, opts Options) Result
+	// Check reports whether the engine can honor opts at all.
+	Check(opts Options) error
+	// Close releases what the engine keeps between evaluations.
+	Close()
+	// Describe names the engine and its version for the status bar.
+	Describe() string
+}
+
+// Result is the outcome of one evaluation.
+type Result struct {
+	Output string
+	// Errors holds diagnostics, what jq prints to stderr.
+	Errors   string
+	ExitCode int
+	// Dropped is the number of output bytes beyond the output limit.
+	Dropped int
+}
+
+// jq indents by DefaultIndent spaces unless told otherwise, and by at most
+// MaxIndent.
+const (
+	DefaultIndent = 2
+	MaxIndent     = 7
+)
+
+// Options are the jq settings that apply to every evaluation.
+type Options struct {
+	// Args are passed verbatim to jq, before the filter.
+	Args    []string
+	Vars    []Variable
+	Raw     bool
+	Compact bool
+	Slurp   bool
+	// SortKeys, Tab and Indent format the output like jq's flags of the
+	// same names. An Indent of 0 is jq's default of 2.
+	SortKeys bool
+	Tab      bool
+	Indent   int
+	// ASCIIOutput escapes every character outside ASCII, like jq
+	// --ascii-output.
+	ASCIIOutput bool
+	// NullInput runs the filter once with null as input; the document is
+	// still available through input and inputs.
+	NullInput bool
+	// Stream reads the input as [path, leaf] events, like jq --stream.
+	Stream bool
+	// Monochrome leaves the output uncolored, for terminals without
+	// colors.
+	Monochrome bool
+	// LibPaths are the directories to search for modules, like jq -L.
+	LibPaths []string
+	// Output, if set, receives the output as jq writes it, so that it can
+	// be shown before jq exits and is kept within the buffer's limit.
+	Output *OutputBuffer
+}
+
+// Flags returns opts as jq command-line flags.
+func (opts Options) Flags() []string {
+	var flags []string
+	if opts.Raw {
+		flags = append(flags, "--raw-output")
+	}
+	if opts.Compact {
+		flags = append(flags, "--compact-output")
+	}
+	if opts.SortKeys {
+		flags = append(flags, "--sort-keys")
+	}
+	switch {
+	case opts.Tab:
+		flags = append(flags, "--tab")
+	case opts.Indent != 0 && opts.Indent != DefaultIndent:
+		flags = append(flags, "--indent", strconv.Itoa(opts.Indent))
+	}
+	if opts.ASCIIOutput {
+		flags = append(flags, "--ascii-output")
+	}
+	if opts.Slurp {
+		flags = append(flags, "--slurp")
+	}
+	if opts.NullInput {
+		flags = append(flags, "--null-input")
+	}
+	if opts.Stream {
+		flags = append(flags, "--stream")
+	}
+	for _, dir := range opts.LibPaths {
+		flags = append(flags, "-L", dir)
+	}
+	for _, v := range opts.Vars {
+		flags = append(flags, v.flags()...)
+	}
+	return append(flags, opts.Args...)
+}
+
+// RawOutput reports whether jq prints strings raw rather than as JSON, by
+// --raw-output or by the flags passed to it after --.
+func (opts Options) RawOutput() bool {
+	if opts.Raw {
+		return true
+	}
+	for _, arg := range opts.Args {
+		switch {
+		case arg == "--raw-output" || arg == "--join-output" || arg == "--raw-output0":
+			return true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rj"):
+			return true
+		}
+	}
+	return false
+}
+
+// Toggles returns short names of the active options for the status line.
+func (opts Options) Toggles() []string {
+	var ts []string
+	if opts.Raw {
+		ts = append(ts, "raw")
+	}
+	if opts.Compact {
+		ts = append(ts, "compact")
+	}
+	if opts.SortKeys {
+		ts = append(ts, "sort-keys")
+	}
+	switch {
+	case opts.Tab:
+		ts = append(ts, "tab")
+	case opts.Indent != 0 && opts.Indent != DefaultIndent:
+		ts = append(ts, fmt.Sprintf("indent %d", opts.Indent))
+	}
+	if opts.ASCIIOutput {
+		ts = append(ts, "ascii")
+	}
+	if opts.Slurp {
+		ts = append(ts, "slurp")
+	}
+	if opts.NullInput {
+		ts = append(ts, "null-input")
+	}
+	if opts.Stream {
+		ts = append(ts, "stream")
+	}
+	return ts
+}
+
+// _defaultJQ is the jq binary run unless --jq-path names another.
+const _defaultJQ = "jq"
+
+// New resolves an engine by name. An empty name selects the jq binary
+// when it is installed and the embedded gojq otherwise. jqPath is the jq
+// binary to run instead of jq in $PATH; ijq does not fall back to gojq
+// when it is missing, as it was asked for. Any other name is that of a
+// jq-compatible binary, such as jaq, or its path, such as $HOME/go/bin/gojq
+// for the gojq command.
+func New(name, jqPath string) (Engine, error) {
+	switch name {
+	case "":
+		if jqPath == "" {
+			if e, err := newJQEngine(_defaultJQ); err == nil {
+				return e, nil
+			}
+			return GojqEngine{}, nil
+		}
+		fallthrough
+	case "jq":
+		e, err := newJQEngine(cmp.Or(jqPath, _defaultJQ))
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	case "gojq":
+		return GojqEngine{}, nil
+	default:
+		e, err := newJQEngine(name)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+}
+
+// jqEngine runs an external jq binary, or another that takes the same
+// arguments, in its dialect.
+type jqEngine struct {
+	path string
+	// version is what jq --version printed, if it could be run.
+	version string
+	dialect dialect
+	// spill holds a large input for jq to read as a file.
+	spill *spillFile
+}
+
+// newJQEngine runs the jq binary at path, which is looked up in $PATH if
+// it has no slash in it, such as jq-1.7.
+func newJQEngine(path string) (jqEngine, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return jqEngine{}, fmt.Errorf("%s: command not found", path)
+	}
+	out, _ := exec.Command(resolved, "--version").Output()
+	version := strings.TrimSpace(string(out))
+	return jqEngine{
+		path:    resolved,
+		version: version,
+		dialect: detectDialect(resolved, version),
+		spill:   &spillFile{},
+	}, nil
+}
+
+func (e jqEngine) Describe() string {
+	return fmt.Sprintf("%s (%s)", cmp.Or(e.version, "jq"), e.path)
+}
+
+func (e jqEngine) Eval(ctx context.Context, content, filter string, opts Options) Result {
+	// jq's output is colored by ColorJSON, which follows the theme, keys
+	// included, whatever the version of jq; raw output is left to jq, as
+	// only jq knows the raw strings from the JSON texts.
+	highlight := !opts.Monochrome && !opts.RawOutput()
+	color := "--monochrome-output"
+	if !opts.Monochrome && !highlight {
+		color = "--color-output"
+	}
+	flags, err := e.dialect.translate(opts.Flags())
+	if err != nil {
+		return Result{Errors: err.Error() + "\n", ExitCode: 2}
+	}
+	args := append([]string{color}, flags...)
+	args = append(args, cmp.Or(filter, "."))
+	var stdin io.Reader = strings.NewReader(content)
+	// With --args, trailing arguments are not files.
+	if len(content) >= _spillSize && !slices.Contains(opts.Args, "--args") && !slices.Contains(opts.Args, "--jsonargs") {
+		if path, err := e.spill.file(content); err == nil {
+			args, stdin = append(args, path), nil
+		}
+	}
+	cmd := exec.CommandContext(ctx, e.path, args...)
+	cmd.Env = append(os.Environ(), e.dialect.colors(jqColors()))
+	cmd.Stdin = stdin
+	stdout := cmp.Or(opts.Output, &OutputBuffer{})
+	var stderr strings.Builder
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	res := Result{Output: stdout.Text(), Errors: stderr.String(), Dropped: stdout.dropped()}
+	if highlight {
+		res.Output = ColorJSON(res.Output)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		res.Errors += err.Error() + "\n"
+		res.ExitCode = 2
+	}
+	return res
+}
+
+func (e jqEngine) Check(opts Options) error {
+	_, err := e.dialect.translate(opts.Flags())
+	return err
+}
+
+func (e jqEngine) Close() {
+	e.spill.close()
+}
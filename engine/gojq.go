@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/itchyny/gojq"
+)
+
+// GojqEngine evaluates filters in-process with github.com/itchyny/gojq, so
+// ijq keeps working when no jq binary is installed.
+type GojqEngine struct{}
+
+func (GojqEngine) Check(opts Options) error {
+	if len(opts.Args) > 0 {
+		return errors.New("jq arguments after -- require the jq engine")
+	}
+	return nil
+}
+
+func (GojqEngine) Close() {}
+
+func (GojqEngine) Describe() string {
+	_, _, _, ver := buildInfo()
+	return "gojq " + ver
+}
+
+func (GojqEngine) Eval(ctx context.Context, content, filter string, opts Options) Result {
+	r := &gojqRun{opts: opts}
+	filter = cmp.Or(filter, ".")
+	query, err := gojq.Parse(filter)
+	if err != nil {
+		var perr *gojq.ParseError
+		if errors.As(err, &perr) {
+			err = fmt.Errorf("%w at <top-level>, offset %d:\n%s", err, perr.Offset, caretLine(filter, perr.Offset))
+		}
+		r.fail(3, err)
+		return r.result()
+	}
+	var inputs gojq.Iter = newJSONIter(content)
+	if opts.Stream {
+		inputs = &streamIter{it: inputs}
+	}
+	if opts.Slurp {
+		inputs = slurp(inputs)
+	}
+	names, values := gojqVariables(opts.Vars)
+	r.values = values
+	r.code, err = gojq.Compile(query,
+		gojq.WithEnvironLoader(os.Environ),
+		gojq.WithModuleLoader(gojq.NewModuleLoader(modulePaths(opts.LibPaths))),
+		gojq.WithInputIter(inputs),
+		gojq.WithVariables(names),
+	)
+	if err != nil {
+		r.fail(3, err)
+		return r.result()
+	}
+
+	if opts.NullInput {
+		r.run(ctx, nil)
+		return r.result()
+	}
+	for {
+		v, ok := inputs.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			r.fail(2, err)
+			break
+		}
+		if !r.run(ctx, v) {
+			break
+		}
+	}
+	return r.result()
+}
+
+// gojqVariables returns the names and values of vars, plus $ARGS as jq
+// defines it.
+func gojqVariables(vars []Variable) ([]string, []any) {
+	names := make([]string, 0, len(vars)+1)
+	values := make([]any, 0, len(vars)+1)
+	named := make(map[string]any, len(vars))
+	for _, v := range vars {
+		var value any = v.value
+		if v.json {
+			dec := json.NewDecoder(strings.NewReader(v.value))
+			dec.UseNumber()
+			_ = dec.Decode(&value)
+		}
+		names = append(names, "$"+v.name)
+		values = append(values, value)
+		named[v.name] = value
+	}
+	names = append(names, "$ARGS")
+	values = append(values, map[string]any{"named": named, "positional": []any{}})
+	return names, values
+}
+
+// gojqRun collects the output and exit status of one evaluation, using the
+// same exit codes as jq.
+type gojqRun struct {
+	code     *gojq.Code
+	values   []any
+	opts     Options
+	sb       strings.Builder
+	errs     strings.Builder
+	exitCode int
+}
+
+func (r *gojqRun) result() Result {
+	return Result{Output: r.sb.String(), Errors: r.errs.String(), ExitCode: r.exitCode}
+}
+
+func (r *gojqRun) fail(exitCode int, err error) {
+	fmt.Fprintf(&r.errs, "gojq: error: %v\n", err)
+	r.exitCode = exitCode
+}
+
+// run writes every output of the filter for input v. It reports whether
+// evaluation should continue with the next input.
+func (r *gojqRun) run(ctx context.Context, v any) bool {
+	iter := r.code.RunWithContext(ctx, v, r.values...)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return true
+		}
+		if err, ok := v.(error); ok {
+			var herr *gojq.HaltError
+			if errors.As(err, &herr) {
+				if s, ok := herr.Value().(string); ok {
+					r.errs.WriteString(s)
+				} else if herr.Value() != nil {
+					fmt.Fprintf(&r.errs, "gojq: error: %v\n", err)
+				}
+				r.exitCode = herr.ExitCode()
+				return false
+			}
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return false
+			}
+			r.fail(5, err)
+			continue
+		}
+		if s, ok := v.(string); ok && r.opts.Raw && !r.opts.ASCIIOutput {
+			r.sb.WriteString(s)
+		} else {
+			NewColorEncoder(&r.sb, r.opts).Encode(v, 0)
+		}
+		r.sb.WriteByte('\n')
+	}
+}
+
+// slurp collects every value of it into a single array, like jq --slurp.
+// A decoding error is passed through instead.
+func slurp(it gojq.Iter) gojq.Iter {
+	vs := []any{}
+	for {
+		v, ok := it.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return gojq.NewIter(err)
+		}
+		vs = append(vs, v)
+	}
+	return gojq.NewIter[any](vs)
+}
+
+// jsonIter yields the JSON values of a document one by one. It doubles as
+// the source of jq's input and inputs builtins.
+type jsonIter struct {
+	dec *json.Decoder
+	err bool
+}
+
+func newJSONIter(content string) *jsonIter {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	return &jsonIter{dec: dec}
+}
+
+func (it *jsonIter) Next() (any, bool) {
+	if it.err {
+		return nil, false
+	}
+	var v any
+	if err := it.dec.Decode(&v); err != nil {
+		if err == io.EOF {
+			return nil, false
+		}
+		it.err = true
+		return err, true
+	}
+	return v, true
+}
+
+// caretLine points at the given byte offset of the last line of src, like
+// the excerpt jq prints with syntax errors.
+func caretLine(src string, offset int) string {
+	offset = min(offset, len(src))
+	start := strings.LastIndexByte(src[:offset], '\n') + 1
+	end := strings.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+	col := lipgloss.Width(src[start:offset])
+	return src[start:end] + "\n" + strings.Repeat(" ", max(col-1, 0)) + "^"
+}
+
+// _toStream is compiled once to turn input values into stream events for
+// gojq, as jq --stream reads them.
+var _toStream = func() *gojq.Code {
+	q, err := gojq.Parse("tostream")
+	if err != nil {
+		panic(err)
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}()
+
+// streamIter yields the stream events of the values of it.
+type streamIter struct {
+	it     gojq.Iter
+	events gojq.Iter
+}
+
+func (s *streamIter) Next() (any, bool) {
+	for {
+		if s.events != nil {
+			if v, ok := s.events.Next(); ok {
+				return v, true
+			}
+			s.events = nil
+		}
+		v, ok := s.it.Next()
+		if !ok {
+			return nil, false
+		}
+		if _, ok := v.(error); ok {
+			return v, true
+		}
+		s.events = _toStream.Run(v)
+	}
+}
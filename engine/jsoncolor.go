@@ -0,0 +1,296 @@
+package engine
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/itchyny/gojq"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// ColorJSON colors the JSON texts in s as jq --color-output would, in the
+// JSON colors of the theme: keys, strings, numbers and literals by their
+// kind, and brackets, commas and colons by the container they belong to.
+// It works token by token, so that partial output and text that is not
+// JSON, such as jq's --seq separators, pass through, uncolored if need be.
+func ColorJSON(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) * 2)
+	// containers holds the open brackets, so that commas take the color of
+	// the innermost.
+	var containers []byte
+	container := func() string {
+		if len(containers) > 0 && containers[len(containers)-1] == '[' {
+			return ColorArray
+		}
+		return ColorObject
+	}
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n', '\r':
+			sb.WriteByte(c)
+			i++
+		case '{', '[':
+			containers = append(containers, c)
+			Colorize(&sb, container(), string(c))
+			i++
+		case '}', ']':
+			color := ColorObject
+			if c == ']' {
+				color = ColorArray
+			}
+			if len(containers) > 0 {
+				containers = containers[:len(containers)-1]
+			}
+			Colorize(&sb, color, string(c))
+			i++
+		case ',':
+			Colorize(&sb, container(), ",")
+			i++
+		case ':':
+			Colorize(&sb, ColorObject, ":")
+			i++
+		case '"':
+			end := StringEnd(s, i)
+			color := ColorString
+			if IsKey(s[end:]) {
+				color = ColorKey
+			}
+			Colorize(&sb, color, s[i:end])
+			i = end
+		default:
+			end := i + 1
+			for end < len(s) && !strings.ContainsRune(" \t\n\r,:{}[]\"", rune(s[end])) {
+				end++
+			}
+			switch word := s[i:end]; {
+			case word == "null":
+				Colorize(&sb, ColorNull, word)
+			case word == "true":
+				Colorize(&sb, ColorTrue, word)
+			case word == "false":
+				Colorize(&sb, ColorFalse, word)
+			case c == '-' || c >= '0' && c <= '9':
+				Colorize(&sb, ColorNumber, word)
+			default:
+				sb.WriteString(word)
+			}
+			i = end
+		}
+	}
+	return sb.String()
+}
+
+// jq's default colors, see JQ_COLORS in jq(1). A theme or JQ_COLORS may
+// change them.
+var (
+	ColorNull   = "1;30"
+	ColorFalse  = "0;39"
+	ColorTrue   = "0;39"
+	ColorNumber = "0;39"
+	ColorString = "0;32"
+	ColorArray  = "1;39"
+	ColorObject = "1;39"
+	ColorKey    = "34;1"
+)
+
+// Colorize writes s to sb in the SGR color.
+func Colorize(sb *strings.Builder, color, s string) {
+	sb.WriteString("\x1b[" + color + "m" + s + "\x1b[0m")
+}
+
+// ColorEncoder prints values in the same colors as jq --color-output,
+// unless monochrome is set, indenting nested values by indent, or
+// compactly if indent is empty. Like gojq, it always sorts the keys of
+// objects.
+type ColorEncoder struct {
+	sb         *strings.Builder
+	indent     string
+	ascii      bool
+	monochrome bool
+}
+
+// NewColorEncoder returns an encoder writing to sb, formatted as opts ask.
+func NewColorEncoder(sb *strings.Builder, opts Options) *ColorEncoder {
+	e := &ColorEncoder{sb: sb, ascii: opts.ASCIIOutput, monochrome: opts.Monochrome}
+	switch {
+	case opts.Compact:
+	case opts.Tab:
+		e.indent = "\t"
+	default:
+		e.indent = strings.Repeat(" ", cmp.Or(opts.Indent, DefaultIndent))
+	}
+	return e
+}
+
+func (e *ColorEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+	e.sb.WriteByte('\n')
+	e.sb.WriteString(strings.Repeat(e.indent, depth))
+}
+
+func (e *ColorEncoder) colorize(color, s string) {
+	if e.monochrome {
+		e.sb.WriteString(s)
+		return
+	}
+	Colorize(e.sb, color, s)
+}
+
+// Encode writes v, a JSON value as gojq represents it, nested depth deep.
+func (e *ColorEncoder) Encode(v any, depth int) {
+	sb := e.sb
+	switch v := v.(type) {
+	case nil:
+		e.colorize(ColorNull, "null")
+	case bool:
+		if v {
+			e.colorize(ColorTrue, "true")
+		} else {
+			e.colorize(ColorFalse, "false")
+		}
+	case string:
+		e.colorize(ColorString, e.quote(v))
+	case []any:
+		if len(v) == 0 {
+			e.colorize(ColorArray, "[]")
+			return
+		}
+		e.colorize(ColorArray, "[")
+		for i, x := range v {
+			if i > 0 {
+				e.colorize(ColorArray, ",")
+			}
+			e.newline(depth + 1)
+			e.Encode(x, depth+1)
+		}
+		e.newline(depth)
+		e.colorize(ColorArray, "]")
+	case map[string]any:
+		if len(v) == 0 {
+			e.colorize(ColorObject, "{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		e.colorize(ColorObject, "{")
+		for i, k := range keys {
+			if i > 0 {
+				e.colorize(ColorObject, ",")
+			}
+			e.newline(depth + 1)
+			e.colorize(ColorKey, e.quote(k))
+			e.colorize(ColorObject, ":")
+			if e.indent != "" {
+				sb.WriteByte(' ')
+			}
+			e.Encode(v[k], depth+1)
+		}
+		e.newline(depth)
+		e.colorize(ColorObject, "}")
+	default:
+		b, _ := gojq.Marshal(v)
+		e.colorize(ColorNumber, string(b))
+	}
+}
+
+// quote returns s as a JSON string, with the characters outside ASCII
+// escaped as \uXXXX, in surrogate pairs beyond the BMP, if e.ascii is set.
+func (e *ColorEncoder) quote(s string) string {
+	b, _ := gojq.Marshal(s)
+	if !e.ascii {
+		return string(b)
+	}
+	var sb strings.Builder
+	for _, r := range string(b) {
+		switch {
+		case r < utf8.RuneSelf:
+			sb.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&sb, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&sb, "\\u%04x", r)
+		}
+	}
+	return sb.String()
+}
+
+// JQColorRe matches the value of JQ_COLORS: up to 8 SGR parameter lists
+// separated by colons.
+var JQColorRe = regexp.MustCompile(`^[0-9;]*(?::[0-9;]*){0,7}$`)
+
+// _jsonColors are the JSON colors in the order of JQ_COLORS.
+var _jsonColors = []*string{
+	&ColorNull, &ColorFalse, &ColorTrue, &ColorNumber,
+	&ColorString, &ColorArray, &ColorObject, &ColorKey,
+}
+
+// SetJSONColors sets the JSON colors given in the format of JQ_COLORS,
+// leaving those it does not give.
+func SetJSONColors(s string) {
+	if s == "" {
+		return
+	}
+	for i, c := range strings.Split(s, ":") {
+		if c != "" {
+			*_jsonColors[i] = c
+		}
+	}
+}
+
+// jqColors returns the JSON colors in the format of JQ_COLORS, for jq to
+// print the result in.
+func jqColors() string {
+	colors := make([]string, len(_jsonColors))
+	for i, c := range _jsonColors {
+		colors[i] = *c
+	}
+	return strings.Join(colors, ":")
+}
+
+// StringEnd returns the offset just past the JSON string starting at s[i],
+// or len(s) if it is not closed.
+func StringEnd(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(s)
+}
+
+// IsKey reports whether a colon follows, ignoring spaces and colors, which
+// makes the string before s an object key.
+func IsKey(s string) bool {
+	for i := 0; i < len(s); {
+		if n := ansiutil.EscapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		switch s[i] {
+		case ' ', '\t':
+			i++
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// _jqLibrary is the user's jq library: a file of definitions that jq reads
+// before every filter, or a directory of modules.
+const _jqLibrary = "~/.jq"
+
+// _defaultLibPaths is jq's module search path, used unless -L is given.
+var _defaultLibPaths = []string{_jqLibrary, "$ORIGIN/../lib/jq", "$ORIGIN/../lib"}
+
+// modulePaths returns where the embedded gojq looks for the modules that
+// a filter imports: the -L directories, if any, or jq's default search
+// path. The user's library is always among them, so that a ~/.jq file is
+// read, as jq does, even with -L.
+func modulePaths(libPaths []string) []string {
+	if len(libPaths) == 0 {
+		return _defaultLibPaths
+	}
+	return append(libPaths[:len(libPaths):len(libPaths)], _jqLibrary)
+}
+
+// LibraryFuncs returns the signatures of the functions defined in the
+// user's ~/.jq file, in the format of _builtins, for completion. It
+// returns none if there is no such file or it does not parse.
+func LibraryFuncs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	src, err := os.ReadFile(filepath.Join(home, ".jq"))
+	if err != nil {
+		return nil
+	}
+	// A library is a list of definitions, which make a filter with a body.
+	q, err := gojq.Parse(string(src) + " .")
+	if err != nil {
+		return nil
+	}
+	sigs := make([]string, 0, len(q.FuncDefs))
+	for _, fd := range q.FuncDefs {
+		sig := fd.Name
+		if len(fd.Args) > 0 {
+			sig += "(" + strings.Join(fd.Args, "; ") + ")"
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var _varName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Variable is a named value bound with --arg or, if json is set, --argjson.
+// If file is set, it is bound with --rawfile or --slurpfile instead, and
+// value holds the contents of the file, or an array of its JSON values.
+type Variable struct {
+	name  string
+	value string
+	json  bool
+	file  string
+}
+
+// ParseVariable parses the panel syntax: name=string binds a string and
+// name:=json binds a JSON value, while name@=file binds the contents of a
+// file and name:@=file an array of the JSON values in it.
+func ParseVariable(s string) (Variable, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Variable{}, fmt.Errorf("expected name=value or name:=json, got %q", s)
+	}
+	v := Variable{name: strings.TrimPrefix(name, "$"), value: value}
+	if n, ok := strings.CutSuffix(v.name, "@"); ok {
+		v.name, v.file = n, value
+	}
+	if n, ok := strings.CutSuffix(v.name, ":"); ok {
+		v.name, v.json = n, true
+	}
+	if strings.HasSuffix(name, "@") {
+		if v.file == "" {
+			return Variable{}, fmt.Errorf("$%s: expected a file name", v.name)
+		}
+		if err := v.load(); err != nil {
+			return Variable{}, err
+		}
+	}
+	return v, v.validate()
+}
+
+// load reads the file of v into its value.
+func (v *Variable) load() error {
+	b, err := os.ReadFile(v.file)
+	if err != nil {
+		return fmt.Errorf("$%s: %w", v.name, err)
+	}
+	if !v.json {
+		v.value = string(b)
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	values := []any{}
+	for {
+		var value any
+		if err := dec.Decode(&value); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("$%s: %s: %w", v.name, v.file, err)
+		}
+		values = append(values, value)
+	}
+	b, err = json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	v.value = string(b)
+	return nil
+}
+
+func (v Variable) validate() error {
+	if !_varName.MatchString(v.name) {
+		return fmt.Errorf("invalid variable name %q", v.name)
+	}
+	if v.json && !json.Valid([]byte(v.value)) {
+		return fmt.Errorf("$%s: invalid JSON text %q", v.name, v.value)
+	}
+	return nil
+}
+
+func (v Variable) String() string {
+	switch {
+	case v.file != "" && v.json:
+		return v.name + ":@=" + v.file
+	case v.file != "":
+		return v.name + "@=" + v.file
+	case v.json:
+		return v.name + ":=" + v.value
+	}
+	return v.name + "=" + v.value
+}
+
+// flags returns the jq flags binding v. A file is passed by its name, as
+// its contents may be too long for a command line argument.
+func (v Variable) flags() []string {
+	switch {
+	case v.file != "" && v.json:
+		return []string{"--slurpfile", v.name, v.file}
+	case v.file != "":
+		return []string{"--rawfile", v.name, v.file}
+	case v.json:
+		return []string{"--argjson", v.name, v.value}
+	}
+	return []string{"--arg", v.name, v.value}
+}
+
+// ExtractVars removes --arg, --argjson, --rawfile and --slurpfile, which
+// take two values and so cannot be declared with the flag package, from
+// args.
+func ExtractVars(args []string) (rest []string, vars []Variable, err error) {
+	for i := 0; i < len(args); i++ {
+		var isJSON, isFile bool
+		switch args[i] {
+		case "-arg", "--arg":
+		case "-argjson", "--argjson":
+			isJSON = true
+		case "-rawfile", "--rawfile":
+			isFile = true
+		case "-slurpfile", "--slurpfile":
+			isJSON, isFile = true, true
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+2 >= len(args) {
+			return nil, nil, fmt.Errorf("%s takes two parameters (e.g. %s name value)", args[i], args[i])
+		}
+		v := Variable{name: args[i+1], value: args[i+2], json: isJSON}
+		if isFile {
+			v.file = v.value
+			if err := v.load(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := v.validate(); err != nil {
+			return nil, nil, err
+		}
+		vars = append(vars, v)
+		i += 2
+	}
+	return rest, vars, nil
+}
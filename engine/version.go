@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata, set by release builds with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+// Otherwise they are filled in from the build info that go install records.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo returns the version, commit and build date of ijq, and the
+// version of the embedded gojq.
+func buildInfo() (ver, rev, built, gojq string) {
+	ver, rev, built, gojq = version, commit, date, "unknown"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if ver == "" && info.Main.Version != "" {
+		ver = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if rev == "" {
+				rev = s.Value
+			}
+		case "vcs.time":
+			if built == "" {
+				built = s.Value
+			}
+		case "vcs.modified":
+			if s.Value == "true" && commit == "" {
+				rev += " (modified)"
+			}
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/itchyny/gojq" {
+			gojq = dep.Version
+		}
+	}
+	return
+}
+
+// WriteVersion writes what ijq --version shows: the build metadata of ijq
+// and the jq it would run, jqPath or jq in $PATH.
+func WriteVersion(w io.Writer, jqPath string) error {
+	ver, rev, built, gojq := buildInfo()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ijq %s\n", orUnknown(ver))
+	fmt.Fprintf(&sb, "commit: %s\n", orUnknown(rev))
+	fmt.Fprintf(&sb, "built: %s\n", orUnknown(built))
+	fmt.Fprintf(&sb, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	switch jq, err := newJQEngine(cmp.Or(jqPath, _defaultJQ)); {
+	case err != nil && jqPath == "":
+		sb.WriteString("jq: not found, using the embedded gojq\n")
+	case err != nil:
+		fmt.Fprintf(&sb, "jq: %v\n", err)
+	case jq.version == "":
+		fmt.Fprintf(&sb, "jq: %s (version unknown)\n", jq.path)
+	default:
+		fmt.Fprintf(&sb, "jq: %s (%s)\n", jq.version, jq.path)
+	}
+	fmt.Fprintf(&sb, "gojq: %s\n", gojq)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+// Package ansiutil measures and cuts text with ANSI escape sequences.
+package ansiutil
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// EscapeLen returns the length of the escape sequence at the start of s, or
+// 0 if s does not start with one. CSI sequences such as the SGR colors jq
+// prints are recognized; any other ESC is taken with the byte after it.
+func EscapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b {
+		return 0
+	}
+	if s[1] != '[' {
+		return 2
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= 0x40 && c <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// CutLeft removes the first n columns of printable text from s, keeping
+// every escape sequence so that colors carry over to the rest of the line.
+// A wide character split by the cut is replaced by spaces.
+func CutLeft(s string, n int) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if l := EscapeLen(s[i:]); l > 0 {
+			sb.WriteString(s[i : i+l])
+			i += l
+			continue
+		}
+		if n <= 0 {
+			sb.WriteString(strings.Repeat(" ", -n))
+			sb.WriteString(s[i:])
+			break
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n -= ansi.StringWidth(string(r))
+		i += size
+	}
+	return sb.String()
+}
+
+// sgrLen returns the length of the SGR sequence, such as a color, at the
+// start of s, or 0 if s does not start with one.
+func sgrLen(s string) int {
+	if len(s) < 3 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == 'm':
+			return i + 1
+		case (c < '0' || c > '9') && c != ';':
+			return 0
+		}
+	}
+	return 0
+}
+
+// Sanitize makes text printed by jq safe to show: it keeps the SGR
+// sequences that color it, line breaks and tabs, and replaces every other
+// control character with its Unicode control picture, such as ␛ for ESC,
+// so that a string in the input cannot move the cursor, clear the screen
+// or retitle the terminal. C1 controls and invalid UTF-8 become U+FFFD,
+// and the carriage return of a CRLF line break is dropped.
+func Sanitize(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); {
+		if n := sgrLen(s[i:]); n > 0 {
+			sb.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\n' || r == '\t':
+			sb.WriteRune(r)
+		case r == '\r' && i+1 < len(s) && s[i+1] == '\n':
+		case r < 0x20:
+			sb.WriteRune(0x2400 + r)
+		case r == 0x7f:
+			sb.WriteRune('␡')
+		case r >= 0x80 && r < 0xa0, r == utf8.RuneError && size == 1:
+			sb.WriteRune(utf8.RuneError)
+		default:
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
@@ -0,0 +1,414 @@
+package input
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Binary formats are converted to JSON with byte strings as base64 and
+// map keys that are not strings as their JSON text.
+
+var errTruncated = errors.New("unexpected end of input")
+
+// _maxDepth is how deep values may be nested, as in encoding/json, so that
+// a few bytes of nested arrays cannot exhaust the stack.
+const _maxDepth = 10000
+
+var errTooDeep = errors.New("exceeded max depth")
+
+// binReader reads the big-endian values both MessagePack and CBOR use.
+type binReader struct {
+	b     []byte
+	pos   int
+	depth int
+}
+
+func (r *binReader) more() bool {
+	return r.pos < len(r.b)
+}
+
+func (r *binReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.b)-r.pos) {
+		return nil, errTruncated
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// uint reads an unsigned integer of n bytes.
+func (r *binReader) uint(n int) (uint64, error) {
+	b, err := r.next(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// binaryToJSON decodes every value of src with decode and writes them as
+// indented JSON texts.
+func binaryToJSON(src string, decode func(*binReader) (any, error)) (string, error) {
+	r := &binReader{b: []byte(src)}
+	var sb strings.Builder
+	for r.more() {
+		v, err := decode(r)
+		if err != nil {
+			return "", fmt.Errorf("offset %d: %w", r.pos, err)
+		}
+		writeJSON(&sb, v, "")
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// binaryKey returns the object key for a decoded map key.
+func binaryKey(k any) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	var sb strings.Builder
+	writeJSON(&sb, k, "")
+	return sb.String()
+}
+
+func floatValue(f float64) any {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func uintValue(n uint64) any {
+	return json.Number(strconv.FormatUint(n, 10))
+}
+
+// decodeMsgpack reads one MessagePack value.
+func decodeMsgpack(r *binReader) (any, error) {
+	if r.depth++; r.depth > _maxDepth {
+		return nil, errTooDeep
+	}
+	defer func() { r.depth-- }()
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return uintValue(uint64(c)), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c >= 0x80 && c <= 0x8f:
+		return msgpackMap(r, uint64(c&0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		return msgpackArray(r, uint64(c&0x0f))
+	case c >= 0xa0 && c <= 0xbf:
+		s, err := r.next(uint64(c & 0x1f))
+		return string(s), err
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.next(n)
+		return base64.StdEncoding.EncodeToString(data), err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackExt(r, n)
+	case 0xca:
+		n, err := r.uint(4)
+		return floatValue(float64(math.Float32frombits(uint32(n)))), err
+	case 0xcb:
+		n, err := r.uint(8)
+		return floatValue(math.Float64frombits(n)), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (c - 0xcc))
+		return uintValue(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := r.uint(size)
+		// Sign-extend from the value's width.
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(n<<shift)>>shift, 10)), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return msgpackExt(r, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		s, err := r.next(n)
+		return string(s), err
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackArray(r, n)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackMap(r, n)
+	}
+	return nil, fmt.Errorf("invalid MessagePack type byte 0x%02x", c)
+}
+
+func msgpackArray(r *binReader, n uint64) (any, error) {
+	arr := make([]any, 0, min(n, 1024))
+	for range n {
+		v, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func msgpackMap(r *binReader, n uint64) (any, error) {
+	om := make(orderedMap, 0, min(n, 1024))
+	for range n {
+		k, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		om.set(binaryKey(k), v)
+	}
+	return om, nil
+}
+
+// msgpackExt reads an extension value of n bytes. Timestamps become RFC
+// 3339 strings and other types an object of the type and base64 data.
+func msgpackExt(r *binReader, n uint64) (any, error) {
+	t, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(t[0]) == -1 {
+		var ts time.Time
+		switch len(data) {
+		case 4:
+			ts = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+		case 8:
+			v := binary.BigEndian.Uint64(data)
+			ts = time.Unix(int64(v&(1<<34-1)), int64(v>>34))
+		case 12:
+			ts = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data)))
+		}
+		if !ts.IsZero() {
+			return ts.UTC().Format(time.RFC3339Nano), nil
+		}
+	}
+	return orderedMap{
+		{"type", json.Number(strconv.Itoa(int(int8(t[0]))))},
+		{"data", base64.StdEncoding.EncodeToString(data)},
+	}, nil
+}
+
+// errBreak is returned for the CBOR break code ending an indefinite-length
+// item.
+var errBreak = errors.New("unexpected break")
+
+// decodeCBOR reads one CBOR value.
+func decodeCBOR(r *binReader) (any, error) {
+	if r.depth++; r.depth > _maxDepth {
+		return nil, errTooDeep
+	}
+	defer func() { r.depth-- }()
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	if b[0] == 0xff {
+		return nil, errBreak
+	}
+	var arg uint64
+	indefinite := info == 31
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		if arg, err = r.uint(1 << (info - 24)); err != nil {
+			return nil, err
+		}
+	case indefinite && major >= 2 && major <= 5:
+	default:
+		return nil, fmt.Errorf("invalid CBOR additional information %d", info)
+	}
+	switch major {
+	case 0:
+		return uintValue(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return json.Number(new(big.Int).Sub(big.NewInt(-1), new(big.Int).SetUint64(arg)).String()), nil
+		}
+		return json.Number(strconv.FormatInt(-1-int64(arg), 10)), nil
+	case 2, 3:
+		data, err := cborBytes(r, major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == 3 {
+			return string(data), nil
+		}
+		return base64.StdEncoding.EncodeToString(data), nil
+	case 4:
+		arr := []any{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			v, err := decodeCBOR(r)
+			if indefinite && err == errBreak {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5:
+		om := orderedMap{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			k, err := decodeCBOR(r)
+			if indefinite && err == errBreak {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			om.set(binaryKey(k), v)
+		}
+		return om, nil
+	case 6:
+		return cborTag(r, arg)
+	}
+	switch {
+	case info == 20:
+		return false, nil
+	case info == 21:
+		return true, nil
+	case info == 22, info == 23:
+		return nil, nil
+	case info == 25:
+		return floatValue(halfFloat(uint16(arg))), nil
+	case info == 26:
+		return floatValue(float64(math.Float32frombits(uint32(arg)))), nil
+	case info == 27:
+		return floatValue(math.Float64frombits(arg)), nil
+	}
+	return json.Number(strconv.FormatUint(arg, 10)), nil
+}
+
+// cborBytes reads the data of a byte or text string, joining the chunks of
+// an indefinite-length one.
+func cborBytes(r *binReader, major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return r.next(n)
+	}
+	var data []byte
+	for {
+		chunk, err := decodeCBOR(r)
+		if err == errBreak {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		s, ok := chunk.(string)
+		if !ok {
+			return nil, errors.New("invalid chunk in indefinite-length string")
+		}
+		if major == 2 {
+			b, _ := base64.StdEncoding.DecodeString(s)
+			data = append(data, b...)
+		} else {
+			data = append(data, s...)
+		}
+	}
+}
+
+// cborTag reads the value of a tag. Bignums become numbers and epoch
+// times RFC 3339 strings; other tags are dropped.
+func cborTag(r *binReader, tag uint64) (any, error) {
+	v, err := decodeCBOR(r)
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case 1:
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil {
+				sec, frac := math.Modf(f)
+				return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(time.RFC3339Nano), nil
+			}
+		}
+	case 2, 3:
+		if s, ok := v.(string); ok {
+			b, _ := base64.StdEncoding.DecodeString(s)
+			n := new(big.Int).SetBytes(b)
+			if tag == 3 {
+				n.Sub(big.NewInt(-1), n)
+			}
+			return json.Number(n.String()), nil
+		}
+	}
+	return v, nil
+}
+
+// halfFloat converts an IEEE 754 half-precision number.
+func halfFloat(h uint16) float64 {
+	exp, frac := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(frac, -24)
+	case 31:
+		f = math.Inf(1)
+		if frac != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(frac+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
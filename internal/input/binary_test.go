@@ -0,0 +1,417 @@
+package input
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// appendUint appends n big-endian in size bytes.
+func appendUint(b []byte, n uint64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(n>>(8*i)))
+	}
+	return b
+}
+
+// encodeMsgpack appends v in the shortest MessagePack encoding.
+func encodeMsgpack(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int64:
+		switch {
+		case v >= 0:
+			return encodeMsgpack(b, uint64(v))
+		case v >= -32:
+			return append(b, byte(v))
+		case v >= math.MinInt8:
+			return appendUint(append(b, 0xd0), uint64(v), 1)
+		case v >= math.MinInt16:
+			return appendUint(append(b, 0xd1), uint64(v), 2)
+		case v >= math.MinInt32:
+			return appendUint(append(b, 0xd2), uint64(v), 4)
+		}
+		return appendUint(append(b, 0xd3), uint64(v), 8)
+	case uint64:
+		switch {
+		case v <= 0x7f:
+			return append(b, byte(v))
+		case v <= math.MaxUint8:
+			return appendUint(append(b, 0xcc), v, 1)
+		case v <= math.MaxUint16:
+			return appendUint(append(b, 0xcd), v, 2)
+		case v <= math.MaxUint32:
+			return appendUint(append(b, 0xce), v, 4)
+		}
+		return appendUint(append(b, 0xcf), v, 8)
+	case float32:
+		return appendUint(append(b, 0xca), uint64(math.Float32bits(v)), 4)
+	case float64:
+		return appendUint(append(b, 0xcb), math.Float64bits(v), 8)
+	case string:
+		n := uint64(len(v))
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = appendUint(append(b, 0xd9), n, 1)
+		case n <= math.MaxUint16:
+			b = appendUint(append(b, 0xda), n, 2)
+		default:
+			b = appendUint(append(b, 0xdb), n, 4)
+		}
+		return append(b, v...)
+	case []byte:
+		n := uint64(len(v))
+		switch {
+		case n <= math.MaxUint8:
+			b = appendUint(append(b, 0xc4), n, 1)
+		case n <= math.MaxUint16:
+			b = appendUint(append(b, 0xc5), n, 2)
+		default:
+			b = appendUint(append(b, 0xc6), n, 4)
+		}
+		return append(b, v...)
+	case []any:
+		n := uint64(len(v))
+		switch {
+		case n < 16:
+			b = append(b, 0x90|byte(n))
+		case n <= math.MaxUint16:
+			b = appendUint(append(b, 0xdc), n, 2)
+		default:
+			b = appendUint(append(b, 0xdd), n, 4)
+		}
+		for _, e := range v {
+			b = encodeMsgpack(b, e)
+		}
+		return b
+	case orderedMap:
+		n := uint64(len(v))
+		switch {
+		case n < 16:
+			b = append(b, 0x80|byte(n))
+		case n <= math.MaxUint16:
+			b = appendUint(append(b, 0xde), n, 2)
+		default:
+			b = appendUint(append(b, 0xdf), n, 4)
+		}
+		for _, e := range v {
+			b = encodeMsgpack(encodeMsgpack(b, e.key), e.value)
+		}
+		return b
+	}
+	panic(fmt.Sprintf("cannot encode %T", v))
+}
+
+// cborHead appends the head of a CBOR item of the major type with the
+// argument n.
+func cborHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return appendUint(append(b, major<<5|24), n, 1)
+	case n <= math.MaxUint16:
+		return appendUint(append(b, major<<5|25), n, 2)
+	case n <= math.MaxUint32:
+		return appendUint(append(b, major<<5|26), n, 4)
+	}
+	return appendUint(append(b, major<<5|27), n, 8)
+}
+
+// encodeCBOR appends v in the shortest CBOR encoding.
+func encodeCBOR(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case int64:
+		if v < 0 {
+			return cborHead(b, 1, uint64(-1-v))
+		}
+		return cborHead(b, 0, uint64(v))
+	case uint64:
+		return cborHead(b, 0, v)
+	case float32:
+		return appendUint(append(b, 0xfa), uint64(math.Float32bits(v)), 4)
+	case float64:
+		return appendUint(append(b, 0xfb), math.Float64bits(v), 8)
+	case string:
+		return append(cborHead(b, 3, uint64(len(v))), v...)
+	case []byte:
+		return append(cborHead(b, 2, uint64(len(v))), v...)
+	case []any:
+		b = cborHead(b, 4, uint64(len(v)))
+		for _, e := range v {
+			b = encodeCBOR(b, e)
+		}
+		return b
+	case orderedMap:
+		b = cborHead(b, 5, uint64(len(v)))
+		for _, e := range v {
+			b = encodeCBOR(encodeCBOR(b, e.key), e.value)
+		}
+		return b
+	}
+	panic(fmt.Sprintf("cannot encode %T", v))
+}
+
+type binaryCase struct {
+	name string
+	v    any
+	want string
+}
+
+// binaryCases returns values covering every width of every type both
+// encoders write, with their JSON texts.
+func binaryCases() []binaryCase {
+	quote := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	cases := []binaryCase{
+		{"null", nil, `null`},
+		{"true", true, `true`},
+		{"false", false, `false`},
+		{"float32", float32(1.5), `1.5`},
+		{"float64", -0.1, `-0.1`},
+		{"bytes", []byte{1, 2, 3}, `"AQID"`},
+		{"empty array", []any{}, `[]`},
+		{"empty map", orderedMap{}, `{}`},
+		{"nested", orderedMap{{"a", []any{int64(1), orderedMap{{"b", nil}}}}, {"c", "d"}}, `{"a":[1,{"b":null}],"c":"d"}`},
+		{"uint64 max", uint64(math.MaxUint64), `18446744073709551615`},
+		{"long bytes", make([]byte, 300), quote(strings.Repeat("A", 400))},
+	}
+	for _, n := range []int64{0, 23, 24, 127, 128, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32 + 1, math.MaxInt64,
+		-1, -24, -25, -32, -33, -128, -129, -256, -257, -32768, -32769, math.MinInt32, math.MinInt32 - 1, math.MinInt64} {
+		cases = append(cases, binaryCase{fmt.Sprintf("int %d", n), n, fmt.Sprint(n)})
+	}
+	for _, n := range []int{0, 23, 24, 31, 32, 255, 256, 65535, 65536} {
+		s := strings.Repeat("é", n/2) + strings.Repeat("x", n%2)
+		cases = append(cases, binaryCase{fmt.Sprintf("string of %d bytes", n), s, quote(s)})
+	}
+	// Maps stay small, as orderedMap looks up every key it sets; the
+	// widest lengths are covered in TestBinaryToJSON.
+	for _, n := range []int{15, 16, 23, 24, 300} {
+		arr, om := make([]any, n), make(orderedMap, n)
+		keys := make([]string, n)
+		for i := range n {
+			arr[i] = int64(i % 3)
+			om[i] = mapEntry{fmt.Sprint("k", i), true}
+			keys[i] = fmt.Sprintf(`"k%d":true`, i)
+		}
+		want := strings.Trim(strings.Repeat("0,1,2,", n/3+1), ",")
+		want = strings.Join(strings.Split(want, ",")[:n], ",")
+		cases = append(cases,
+			binaryCase{fmt.Sprintf("array of %d", n), arr, "[" + want + "]"},
+			binaryCase{fmt.Sprintf("map of %d", n), om, "{" + strings.Join(keys, ",") + "}"})
+	}
+	return cases
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	formats := []struct {
+		name   string
+		encode func([]byte, any) []byte
+		decode func(*binReader) (any, error)
+	}{
+		{"msgpack", encodeMsgpack, decodeMsgpack},
+		{"cbor", encodeCBOR, decodeCBOR},
+	}
+	for _, f := range formats {
+		for _, tt := range binaryCases() {
+			t.Run(f.name+"/"+tt.name, func(t *testing.T) {
+				src := f.encode(nil, tt.v)
+				out, err := binaryToJSON(string(src), f.decode)
+				if err != nil {
+					t.Fatalf("decoding % x: %v", src[:min(len(src), 16)], err)
+				}
+				if got := compactJSON(t, out); len(got) != 1 || got[0] != tt.want {
+					t.Errorf("decoding % x = %.80q, want %.80q", src[:min(len(src), 16)], got, tt.want)
+				}
+				// Every value cut short fails to decode.
+				for _, n := range truncations(len(src)) {
+					if out, err := binaryToJSON(string(src[:n]), f.decode); !errors.Is(err, errTruncated) {
+						t.Fatalf("decoding the first %d of %d bytes = %.80q, %v; want %v", n, len(src), out, err, errTruncated)
+					}
+				}
+			})
+		}
+	}
+}
+
+// truncations returns the lengths to cut an encoding of n bytes to: every
+// one for short encodings, and the ends of long ones.
+func truncations(n int) []int {
+	var lengths []int
+	for i := 1; i < n; i++ {
+		if n <= 64 || i <= 32 || i >= n-32 {
+			lengths = append(lengths, i)
+		}
+	}
+	return lengths
+}
+
+func TestBinaryToJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func(*binReader) (any, error)
+		hex    string
+		want   []string
+	}{
+		{"msgpack stream", decodeMsgpack, "01a161c0", []string{`1`, `"a"`, `null`}},
+		{"msgpack map 16", decodeMsgpack, "de0001a161c3", []string{`{"a":true}`}},
+		{"msgpack map 32", decodeMsgpack, "df00000001a161c3", []string{`{"a":true}`}},
+		{"msgpack array 32", decodeMsgpack, "dd0000000101", []string{`[1]`}},
+		{"msgpack string 32", decodeMsgpack, "db0000000161", []string{`"a"`}},
+		{"msgpack bin 32", decodeMsgpack, "c60000000101", []string{`"AQ=="`}},
+		{"msgpack non-string key", decodeMsgpack, "8101c3", []string{`{"1":true}`}},
+		{"msgpack timestamp 32", decodeMsgpack, "d6ff00000001", []string{`"1970-01-01T00:00:01Z"`}},
+		{"msgpack timestamp 64", decodeMsgpack, "d7ff0000000400000002", []string{`"1970-01-01T00:00:02.000000001Z"`}},
+		{"msgpack timestamp 96", decodeMsgpack, "c70cff00000000fffffffffffffffe", []string{`"1969-12-31T23:59:58Z"`}},
+		{"msgpack extension", decodeMsgpack, "d40105", []string{`{"type":1,"data":"BQ=="}`}},
+		{"msgpack infinity", decodeMsgpack, "cb7ff0000000000000", []string{`"+Inf"`}},
+		{"cbor half float", decodeCBOR, "f93e00", []string{`1.5`}},
+		{"cbor half float max", decodeCBOR, "f97bff", []string{`65504`}},
+		{"cbor half float subnormal", decodeCBOR, "f90001", []string{`5.960464477539063e-08`}},
+		{"cbor negative bignum", decodeCBOR, "3bffffffffffffffff", []string{`-18446744073709551616`}},
+		{"cbor tagged bignum", decodeCBOR, "c249010000000000000000", []string{`18446744073709551616`}},
+		{"cbor epoch time", decodeCBOR, "c11a514b67b0", []string{`"2013-03-21T20:04:00Z"`}},
+		{"cbor other tag", decodeCBOR, "d82076687474703a2f2f7777772e6578616d706c652e636f6d", []string{`"http://www.example.com"`}},
+		{"cbor indefinite bytes", decodeCBOR, "5f42010243030405ff", []string{`"AQIDBAU="`}},
+		{"cbor indefinite text", decodeCBOR, "7f657374726561646d696e67ff", []string{`"streaming"`}},
+		{"cbor indefinite array", decodeCBOR, "9f018202039f0405ffff", []string{`[1,[2,3],[4,5]]`}},
+		{"cbor indefinite map", decodeCBOR, "bf61610161629f0203ffff", []string{`{"a":1,"b":[2,3]}`}},
+		{"cbor non-string key", decodeCBOR, "a201020304", []string{`{"1":2,"3":4}`}},
+		{"cbor map 64", decodeCBOR, "bb00000000000000016161f5", []string{`{"a":true}`}},
+		{"cbor array 64", decodeCBOR, "9b000000000000000101", []string{`[1]`}},
+		{"cbor undefined", decodeCBOR, "f7", []string{`null`}},
+		{"empty", decodeCBOR, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := binaryToJSON(string(src), tt.decode)
+			if err != nil {
+				t.Fatalf("decoding %s: %v", tt.hex, err)
+			}
+			if got := compactJSON(t, out); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("decoding %s = %q, want %q", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinaryToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func(*binReader) (any, error)
+		hex    string
+		want   string
+	}{
+		{"msgpack invalid type", decodeMsgpack, "c1", "offset 1: invalid MessagePack type byte 0xc1"},
+		{"msgpack truncated string", decodeMsgpack, "a3616263" + "a2", "unexpected end of input"},
+		{"msgpack huge length", decodeMsgpack, "dbffffffff", "unexpected end of input"},
+		{"msgpack truncated string length", decodeMsgpack, "da00", "offset 1: unexpected end of input"},
+		{"msgpack truncated array length", decodeMsgpack, "dd0000", "offset 1: unexpected end of input"},
+		{"msgpack truncated map length", decodeMsgpack, "de00", "offset 1: unexpected end of input"},
+		{"msgpack truncated extension length", decodeMsgpack, "c8ff", "offset 1: unexpected end of input"},
+		{"msgpack huge array", decodeMsgpack, "ddffffffff01", "unexpected end of input"},
+		{"msgpack huge map", decodeMsgpack, "dfffffffffa161c3", "unexpected end of input"},
+		{"msgpack huge bin", decodeMsgpack, "c6ffffffff00", "unexpected end of input"},
+		{"msgpack deep nesting", decodeMsgpack, strings.Repeat("91", _maxDepth) + "c0", "exceeded max depth"},
+		{"msgpack deep map nesting", decodeMsgpack, strings.Repeat("81c0", _maxDepth) + "c0", "exceeded max depth"},
+		{"cbor invalid additional information", decodeCBOR, "1c", "invalid CBOR additional information 28"},
+		{"cbor indefinite integer", decodeCBOR, "1f", "invalid CBOR additional information 31"},
+		{"cbor stray break", decodeCBOR, "ff", "unexpected break"},
+		{"cbor break in map value", decodeCBOR, "bf01ff", "unexpected break"},
+		{"cbor bad chunk", decodeCBOR, "5f01ff", "invalid chunk in indefinite-length string"},
+		{"cbor huge length", decodeCBOR, "7bffffffffffffffff", "unexpected end of input"},
+		{"cbor truncated length", decodeCBOR, "1a0000", "offset 1: unexpected end of input"},
+		{"cbor truncated array length", decodeCBOR, "9b00000000", "offset 1: unexpected end of input"},
+		{"cbor truncated tag", decodeCBOR, "d9", "offset 1: unexpected end of input"},
+		{"cbor huge array", decodeCBOR, "9bffffffffffffffff01", "unexpected end of input"},
+		{"cbor huge map", decodeCBOR, "bbffffffffffffffff6161f5", "unexpected end of input"},
+		{"cbor unterminated indefinite array", decodeCBOR, "9f0102", "unexpected end of input"},
+		{"cbor deep nesting", decodeCBOR, strings.Repeat("81", _maxDepth) + "f6", "exceeded max depth"},
+		{"cbor deep indefinite nesting", decodeCBOR, strings.Repeat("9f", _maxDepth) + "f6", "exceeded max depth"},
+		{"cbor deep tags", decodeCBOR, strings.Repeat("c6", _maxDepth) + "f6", "exceeded max depth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := binaryToJSON(string(src), tt.decode)
+			if err == nil {
+				t.Fatalf("decoding %s = %q, want an error", tt.hex, out)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decoding %s: error %q, want it to contain %q", tt.hex, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestMaxDepth decodes values nested as deep as allowed without writing
+// them, as their indented JSON grows with the square of the depth.
+func TestMaxDepth(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		decode func(*binReader) (any, error)
+		hex    string
+	}{
+		{"msgpack", decodeMsgpack, strings.Repeat("91", _maxDepth-1) + "c0"},
+		{"cbor", decodeCBOR, strings.Repeat("81", _maxDepth-1) + "f6"},
+	} {
+		src, err := hex.DecodeString(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &binReader{b: src}
+		if _, err := tt.decode(r); err != nil || r.more() || r.depth != 0 {
+			t.Errorf("%s: decoding %d nested arrays: %v, depth %d after", tt.name, _maxDepth-1, err, r.depth)
+		}
+	}
+}
+
+func TestHalfFloat(t *testing.T) {
+	for _, tt := range []struct {
+		h    uint16
+		want float64
+	}{
+		{0x0000, 0},
+		{0x3c00, 1},
+		{0xc000, -2},
+		{0x7bff, 65504},
+		{0x0400, math.Ldexp(1, -14)},
+		{0x7c00, math.Inf(1)},
+		{0xfc00, math.Inf(-1)},
+	} {
+		if got := halfFloat(tt.h); got != tt.want {
+			t.Errorf("halfFloat(%#04x) = %v, want %v", tt.h, got, tt.want)
+		}
+	}
+	if got := halfFloat(0x7e00); !math.IsNaN(got) {
+		t.Errorf("halfFloat(0x7e00) = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+var (
+	_gzipMagic = []byte{0x1f, 0x8b}
+	_zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress returns data uncompressed if it starts with the magic bytes
+// of gzip or Zstandard, and as it is otherwise. Zstandard is decoded by
+// the zstd command.
+func decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, _gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		out, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return out, nil
+	case bytes.HasPrefix(data, _zstdMagic):
+		path, err := exec.LookPath("zstd")
+		if err != nil {
+			return nil, errors.New("zstd: reading Zstandard input requires the zstd command")
+		}
+		cmd := exec.Command(path, "--decompress", "--stdout", "--quiet")
+		cmd.Stdin = bytes.NewReader(data)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %s", cmp.Or(strings.TrimSpace(stderr.String()), err.Error()))
+		}
+		return out, nil
+	}
+	return data, nil
+}
+
+// trimCompressionExt removes a .gz or .zst extension from name, so that
+// data.yaml.gz is read as YAML.
+func trimCompressionExt(name string) string {
+	for _, ext := range []string{".gz", ".zst"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package input
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// readCSV splits comma- or otherwise separated values, which may be quoted
+// as in RFC 4180, into records.
+func readCSV(src string, delimiter rune) ([][]string, error) {
+	r := csv.NewReader(strings.NewReader(src))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+// readTSV splits tab- or otherwise separated values into records. Unlike
+// CSV, fields are never quoted.
+func readTSV(src string, delimiter rune) [][]string {
+	if src == "" {
+		return nil
+	}
+	var records [][]string
+	for _, line := range strings.Split(strings.TrimSuffix(src, "\n"), "\n") {
+		records = append(records, strings.Split(strings.TrimSuffix(line, "\r"), string(delimiter)))
+	}
+	return records
+}
+
+// tableToJSON converts records to one JSON array. With a header, every
+// record becomes an object keyed by the header's fields; without one, an
+// array of its fields.
+func tableToJSON(records [][]string, header bool) string {
+	rows := []any{}
+	var keys []string
+	for i, rec := range records {
+		if header && i == 0 {
+			keys = rec
+			continue
+		}
+		if !header {
+			row := make([]any, len(rec))
+			for j, f := range rec {
+				row[j] = f
+			}
+			rows = append(rows, row)
+			continue
+		}
+		row := make(orderedMap, 0, len(keys))
+		for j, f := range rec {
+			key := fmt.Sprint(j + 1)
+			if j < len(keys) {
+				key = keys[j]
+			}
+			row.set(key, f)
+		}
+		rows = append(rows, row)
+	}
+	var sb strings.Builder
+	writeJSON(&sb, rows, "")
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// ParseDelimiter returns the single character named by s, which may be
+// written as \t.
+func ParseDelimiter(s string) (rune, error) {
+	if s == `\t` {
+		return '\t', nil
+	}
+	rs := []rune(s)
+	if len(rs) != 1 || rs[0] == '"' || rs[0] == '\n' || rs[0] == '\r' {
+		return 0, fmt.Errorf("invalid delimiter %q: must be a single character other than a quote or newline", s)
+	}
+	return rs[0], nil
+}
@@ -0,0 +1,98 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		noHeader bool
+		want     string
+	}{
+		{"header", "a,b\n1,2\n3,4\n", false, `[{"a":"1","b":"2"},{"a":"3","b":"4"}]`},
+		{"no header", "a,b\n1,2\n", true, `[["a","b"],["1","2"]]`},
+		{"header only", "a,b\n", false, `[]`},
+		{"empty", "", false, `[]`},
+		{"header order", "z,a,m\n1,2,3\n", false, `[{"z":"1","a":"2","m":"3"}]`},
+		{"quoted fields", "a,b\n\"x, y\",\"say \"\"hi\"\"\"\n", false, `[{"a":"x, y","b":"say \"hi\""}]`},
+		{"quoted newline", "a\n\"one\ntwo\"\n", false, `[{"a":"one\ntwo"}]`},
+		{"quoted header", "\"a,b\",c\n1,2\n", false, `[{"a,b":"1","c":"2"}]`},
+		{"crlf", "a,b\r\n1,2\r\n", false, `[{"a":"1","b":"2"}]`},
+		{"short row", "a,b,c\n1\n", false, `[{"a":"1"}]`},
+		{"long row", "a\n1,2,3\n", false, `[{"a":"1","2":"2","3":"3"}]`},
+		{"duplicate header", "a,a\n1,2\n", false, `[{"a":"2"}]`},
+		{"empty fields", "a,b\n,\n", false, `[{"a":"","b":""}]`},
+		{"numbers stay strings", "n\n007\n", false, `[{"n":"007"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := readCSV(tt.src, ',')
+			if err != nil {
+				t.Fatalf("readCSV(%q): %v", tt.src, err)
+			}
+			if got := compactJSON(t, tableToJSON(records, !tt.noHeader)); strings.Join(got, "\n") != tt.want {
+				t.Errorf("CSV %q = %s, want %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	for _, src := range []string{"a\n\"open\n", "a\nx\"y\n"} {
+		if records, err := readCSV(src, ','); err == nil {
+			t.Errorf("readCSV(%q) = %q, want an error", src, records)
+		}
+	}
+}
+
+func TestTSVToJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		delimiter rune
+		want      string
+	}{
+		{"header", "a\tb\n1\t2\n", '\t', `[{"a":"1","b":"2"}]`},
+		{"quotes are literal", "a\n\"x\"\n", '\t', `[{"a":"\"x\""}]`},
+		{"comma inside field", "a\n1,2\n", '\t', `[{"a":"1,2"}]`},
+		{"crlf", "a\tb\r\n1\t2\r\n", '\t', `[{"a":"1","b":"2"}]`},
+		{"no final newline", "a\n1", '\t', `[{"a":"1"}]`},
+		{"empty field", "a\tb\n\t2\n", '\t', `[{"a":"","b":"2"}]`},
+		{"other delimiter", "a|b\n1|2\n", '|', `[{"a":"1","b":"2"}]`},
+		{"empty", "", '\t', `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compactJSON(t, tableToJSON(readTSV(tt.src, tt.delimiter), true)); strings.Join(got, "\n") != tt.want {
+				t.Errorf("TSV %q = %s, want %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    rune
+		wantErr bool
+	}{
+		{",", ',', false},
+		{`\t`, '\t', false},
+		{"\t", '\t', false},
+		{";", ';', false},
+		{"§", '§', false},
+		{"", 0, true},
+		{",,", 0, true},
+		{`"`, 0, true},
+		{"\n", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDelimiter(tt.s)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseDelimiter(%q) = %q, %v; want %q, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package input
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// RunCommand runs command with the shell and returns what it prints to
+// stdout.
+func RunCommand(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+			return "", fmt.Errorf("%s: %s", command, cmp.Or(msg, err.Error()))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,70 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds fetching an input URL.
+const DefaultHTTPTimeout = 30 * time.Second
+
+// HTTPOptions configure the requests for input URLs.
+type HTTPOptions struct {
+	Header  http.Header
+	Timeout time.Duration
+}
+
+// IsURL reports whether name is an HTTP or HTTPS URL rather than a file.
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// fetchURL copies the body of a GET request for rawURL to dst.
+func fetchURL(dst io.Writer, rawURL string, opts HTTPOptions, p *Progress) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range opts.Header {
+		req.Header[k] = vs
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	p.start(rawURL, resp.ContentLength)
+	return p.copy(dst, resp.Body)
+}
+
+// ParseHeader adds a header given as "Name: value" to h.
+func ParseHeader(h http.Header, s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q: want Name: value", s)
+	}
+	h.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+// inputExt returns the extension of an input file or the path of a URL,
+// ignoring that of a compression format.
+func inputExt(name string) string {
+	if IsURL(name) {
+		if u, err := url.Parse(name); err == nil {
+			name = u.Path
+		}
+	}
+	return path.Ext(trimCompressionExt(name))
+}
@@ -0,0 +1,167 @@
+// Package input reads the documents filters run on, from files, URLs,
+// stdin or commands, converting other formats to JSON.
+package input
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Input formats other than JSON are converted to JSON before ijq shows or
+// evaluates them.
+const (
+	FormatAuto = "auto"
+	FormatJSON = "json"
+	// JSONC is JSON with comments and trailing commas.
+	FormatJSONC = "jsonc"
+	FormatYAML  = "yaml"
+	FormatTOML  = "toml"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatXML   = "xml"
+	// NDJSON, or JSON Lines, has one JSON value per line.
+	FormatNDJSON  = "ndjson"
+	FormatMsgpack = "msgpack"
+	FormatCBOR    = "cbor"
+)
+
+// Formats are the values of --input.
+var Formats = []string{FormatAuto, FormatJSON, FormatJSONC, FormatYAML, FormatTOML, FormatCSV, FormatTSV, FormatXML, FormatNDJSON, FormatMsgpack, FormatCBOR}
+
+// Options say how to read the input.
+type Options struct {
+	Format string
+	// Delimiter separates the fields of CSV and TSV input; zero selects
+	// the format's usual one.
+	Delimiter rune
+	NoHeader  bool
+	// XMLAttrPrefix starts the keys of XML attributes and XMLTextKey holds
+	// the text of elements that also have attributes or children.
+	XMLAttrPrefix string
+	XMLTextKey    string
+}
+
+// Convert converts content to JSON texts. The auto format picks one
+// from the file extensions and the content itself.
+func Convert(content string, files []string, opts Options) (string, error) {
+	format := opts.Format
+	if format == FormatAuto {
+		format = detectFormat(content, files)
+	}
+	var (
+		out string
+		err error
+	)
+	switch format {
+	case FormatJSONC:
+		out, err = jsoncToJSON(content)
+	case FormatYAML:
+		out, err = yamlToJSON(content)
+	case FormatTOML:
+		out, err = tomlToJSON(content)
+	case FormatCSV:
+		var records [][]string
+		if records, err = readCSV(content, cmp.Or(opts.Delimiter, ',')); err == nil {
+			out = tableToJSON(records, !opts.NoHeader)
+		}
+	case FormatTSV:
+		out = tableToJSON(readTSV(content, cmp.Or(opts.Delimiter, '\t')), !opts.NoHeader)
+	case FormatXML:
+		out, err = xmlToJSON(content, opts.XMLAttrPrefix, opts.XMLTextKey)
+	case FormatNDJSON:
+		out, err = readNDJSON(content)
+	case FormatMsgpack:
+		out, err = binaryToJSON(content, decodeMsgpack)
+	case FormatCBOR:
+		out, err = binaryToJSON(content, decodeCBOR)
+	default:
+		return content, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s input: %w", strings.ToUpper(format), err)
+	}
+	return out, nil
+}
+
+// detectFormat guesses the format of content: the one its files' extension
+// names, JSONC for a .json file with comments, XML if it starts with a tag,
+// or YAML if it is not JSON but parses as a YAML mapping or sequence.
+func detectFormat(content string, files []string) string {
+	for _, name := range files {
+		switch strings.ToLower(inputExt(name)) {
+		case ".yaml", ".yml":
+			return FormatYAML
+		case ".toml":
+			return FormatTOML
+		case ".csv":
+			return FormatCSV
+		case ".tsv", ".tab":
+			return FormatTSV
+		case ".xml":
+			return FormatXML
+		case ".ndjson", ".jsonl":
+			return FormatNDJSON
+		case ".msgpack", ".mpk":
+			return FormatMsgpack
+		case ".cbor":
+			return FormatCBOR
+		case ".jsonc":
+			return FormatJSONC
+		case ".json":
+			if hasComments(content) {
+				return FormatJSONC
+			}
+			return FormatJSON
+		}
+	}
+	// Looking at the first character spares reading a large JSON input
+	// twice, once here and once to validate it.
+	t := strings.TrimSpace(content)
+	switch {
+	case t == "" || strings.ContainsRune(`{["`, rune(t[0])):
+		return FormatJSON
+	case t[0] == '<':
+		return FormatXML
+	case isJSON(content):
+		return FormatJSON
+	}
+	if isYAMLCollections(content) {
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// readNDJSON checks that every line of content is one JSON value, so that
+// a broken record is reported by its line, and drops blank lines.
+func readNDJSON(content string) (string, error) {
+	var sb strings.Builder
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			var v any
+			err := json.Unmarshal([]byte(line), &v)
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// CountRecords returns the number of JSON values at the start of content,
+// up to the first that does not parse.
+func CountRecords(content string) int {
+	n, _ := Scan("", content)
+	return n
+}
+
+// isJSON reports whether content is a stream of JSON values.
+func isJSON(content string) bool {
+	_, err := Scan("", content)
+	return err == nil
+}
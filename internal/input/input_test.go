@@ -0,0 +1,32 @@
+package input
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		files   []string
+		want    string
+	}{
+		{"extension", "a: 1", []string{"x.YML"}, FormatYAML},
+		{"json with comments", "{// c\n}", []string{"x.json"}, FormatJSONC},
+		{"first extension known", "{}", []string{"x", "y.toml"}, FormatTOML},
+		{"object", `{"a":1}`, nil, FormatJSON},
+		{"broken object", `{"a":`, nil, FormatJSON},
+		{"flow mapping", "{a: 1}", nil, FormatJSON},
+		{"scalars", "1 true null", nil, FormatJSON},
+		{"empty", " \n", nil, FormatJSON},
+		{"xml", "\n<a/>", nil, FormatXML},
+		{"yaml mapping", "a: 1", nil, FormatYAML},
+		{"yaml sequence", "- a\n- b", nil, FormatYAML},
+		{"yaml scalar", "hello", nil, FormatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat(tt.content, tt.files); got != tt.want {
+				t.Errorf("detectFormat(%q, %q) = %q, want %q", tt.content, tt.files, got, tt.want)
+			}
+		})
+	}
+}
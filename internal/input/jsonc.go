@@ -0,0 +1,59 @@
+package input
+
+import (
+	"errors"
+	"strings"
+)
+
+// jsoncToJSON strips the // and /* */ comments and the trailing commas of
+// JSONC, as in VS Code settings and tsconfig.json, leaving JSON. Comments
+// become spaces, keeping their line breaks, so that errors in the JSON are
+// still reported at the right line and column.
+func jsoncToJSON(src string) (string, error) {
+	out := []byte(src)
+	// comma is the offset of a comma that may turn out to be trailing, or
+	// -1.
+	comma := -1
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			comma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return "", errors.New("unterminated /* comment")
+			}
+			for j := i; j < i+2+end+2; j++ {
+				if out[j] != '\n' {
+					out[j] = ' '
+				}
+			}
+			i += 2 + end + 1
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				out[comma] = ' '
+			}
+			comma = -1
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			comma = -1
+		}
+	}
+	return string(out), nil
+}
+
+// hasComments reports whether content may be JSONC: JSON with comments,
+// which the JSON parser rejects.
+func hasComments(content string) bool {
+	return (strings.Contains(content, "//") || strings.Contains(content, "/*")) && !isJSON(content)
+}
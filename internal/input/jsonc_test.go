@@ -0,0 +1,73 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONCToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"plain JSON", `{"a":[1,2]}`, `{"a":[1,2]}`},
+		{"line comment", "{\"a\":1 // one\n}", "{\"a\":1       \n}"},
+		{"line comment at end", "1 // end", "1       "},
+		{"block comment", `[1 /* one */, 2]`, `[1          , 2]`},
+		{"block comment over lines", "[1 /* a\nb */]", "[1     \n    ]"},
+		{"comment in string", `{"a":"// no","b":"/* no */"}`, `{"a":"// no","b":"/* no */"}`},
+		{"escaped quote in string", `["a\"//b" // c` + "\n]", `["a\"//b"     ` + "\n]"},
+		{"escaped backslash in string", `["a\\" // c` + "\n]", `["a\\"     ` + "\n]"},
+		{"trailing comma in object", `{"a":1,}`, `{"a":1 }`},
+		{"trailing comma in array", "[1,\n  2,\n]", "[1,\n  2 \n]"},
+		{"trailing comma before comment", "[1, // c\n]", "[1      \n]"},
+		{"trailing comma before block comment", "[1, /* c */ ]", "[1          ]"},
+		{"comma in string", `["a,"]`, `["a,"]`},
+		{"comma before string", `[1, "]"]`, `[1, "]"]`},
+		{"nested trailing commas", `{"a":[1,],"b":{"c":2,},}`, `{"a":[1 ],"b":{"c":2 } }`},
+		{"comma between values", `[1,,]`, `[1, ]`},
+		{"slash in number position", `[1/2]`, `[1/2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsoncToJSON(tt.src)
+			if err != nil {
+				t.Fatalf("jsoncToJSON(%q): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("jsoncToJSON(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+			// Offsets, and so lines and columns, are kept.
+			if len(got) != len(tt.src) || strings.Count(got, "\n") != strings.Count(tt.src, "\n") {
+				t.Errorf("jsoncToJSON(%q) = %q, which moves offsets", tt.src, got)
+			}
+		})
+	}
+}
+
+func TestJSONCToJSONErrors(t *testing.T) {
+	for _, src := range []string{"[1 /* open", "/*/", `["/*"] /* x *`} {
+		if out, err := jsoncToJSON(src); err == nil {
+			t.Errorf("jsoncToJSON(%q) = %q, want an error", src, out)
+		}
+	}
+}
+
+func TestHasComments(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"{// c\n}", true},
+		{"[1 /* c */]", true},
+		{`{"url":"http://x"}`, false},
+		{`{"a":1}`, false},
+		{"{,}", false},
+	}
+	for _, tt := range tests {
+		if got := hasComments(tt.content); got != tt.want {
+			t.Errorf("hasComments(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Document is an input file, read as a separate input when several are
+// given.
+type Document struct {
+	Name    string
+	Content string
+}
+
+// LoadDocuments reads and converts each of files as a document of its own,
+// so that the format of each is detected from its own extension.
+func LoadDocuments(files []string, httpOpts HTTPOptions, input Options, p *Progress) ([]Document, error) {
+	docs := make([]Document, 0, len(files))
+	for _, name := range files {
+		content, err := GetContent([]string{name}, false, httpOpts, p)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = Convert(content, []string{name}, input); err != nil {
+			return nil, err
+		}
+		docs = append(docs, Document{Name: name, Content: content})
+	}
+	return docs, nil
+}
+
+// GetContent reads files, which may be URLs, or stdin if there are none,
+// decompressing them if needed. With nullInput, stdin is left alone.
+func GetContent(files []string, nullInput bool, httpOpts HTTPOptions, p *Progress) (string, error) {
+	if len(files) == 0 {
+		if nullInput {
+			return "", nil
+		}
+		var buf bytes.Buffer
+		p.start("stdin", fileSize(os.Stdin))
+		if err := p.copy(&buf, os.Stdin); err != nil {
+			return "", err
+		}
+		b, err := decompress(buf.Bytes())
+		if err != nil {
+			return "", fmt.Errorf("stdin: %w", err)
+		}
+		return string(b), nil
+	}
+	var sb strings.Builder
+	for _, name := range files {
+		var (
+			buf bytes.Buffer
+			err error
+		)
+		if IsURL(name) {
+			err = fetchURL(&buf, name, httpOpts, p)
+		} else {
+			err = readFile(&buf, name, p)
+		}
+		if err != nil {
+			return "", err
+		}
+		b, err := decompress(buf.Bytes())
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		sb.Write(b)
+	}
+	return sb.String(), nil
+}
+
+func readFile(dst io.Writer, name string, p *Progress) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p.start(name, fileSize(f))
+	return p.copy(dst, f)
+}
@@ -0,0 +1,126 @@
+package input
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Input in other formats is converted to JSON keeping the order of its
+// keys, which Go maps would lose.
+
+// orderedMap is a JSON object that remembers the order of
+// its keys.
+type orderedMap []mapEntry
+
+type mapEntry struct {
+	key   string
+	value any
+}
+
+func (om orderedMap) index(key string) int {
+	for i, e := range om {
+		if e.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// set replaces the value of key, or appends it if it is new.
+func (om *orderedMap) set(key string, value any) {
+	if i := om.index(key); i >= 0 {
+		(*om)[i].value = value
+		return
+	}
+	*om = append(*om, mapEntry{key, value})
+}
+
+// decodeOrdered reads the next JSON value from dec, decoding objects to
+// orderedMap.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		om := orderedMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			om.set(key.(string), v)
+		}
+		_, err = dec.Token()
+		return om, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// writeJSON writes v as JSON indented by two spaces.
+func writeJSON(sb *strings.Builder, v any, indent string) {
+	switch v := v.(type) {
+	case orderedMap:
+		if len(v) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		sb.WriteString("{\n")
+		for i, e := range v {
+			sb.WriteString(indent + "  ")
+			writeJSONString(sb, e.key)
+			sb.WriteString(": ")
+			writeJSON(sb, e.value, indent+"  ")
+			if i < len(v)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "}")
+	case []any:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return
+		}
+		sb.WriteString("[\n")
+		for i, x := range v {
+			sb.WriteString(indent + "  ")
+			writeJSON(sb, x, indent+"  ")
+			if i < len(v)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "]")
+	case string:
+		writeJSONString(sb, v)
+	case json.Number:
+		sb.WriteString(v.String())
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	default:
+		sb.WriteString("null")
+	}
+}
+
+func writeJSONString(sb *strings.Builder, s string) {
+	b, _ := json.Marshal(s)
+	sb.Write(b)
+}
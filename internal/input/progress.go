@@ -0,0 +1,119 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// _progressDelay is how long loading the input takes before its progress
+// is shown, and _progressInterval how often it is updated.
+const (
+	_progressDelay    = 300 * time.Millisecond
+	_progressInterval = 100 * time.Millisecond
+)
+
+// Progress counts the bytes read of the input source being loaded. A nil
+// progress counts nothing.
+type Progress struct {
+	mu   sync.Mutex
+	name string
+	read int
+	// size is the size of the source, or 0 if unknown.
+	size int
+}
+
+// start begins counting source name of size bytes.
+func (p *Progress) start(name string, size int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.name, p.read, p.size = name, 0, int(max(size, 0))
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	if p != nil {
+		p.mu.Lock()
+		p.read += len(b)
+		p.mu.Unlock()
+	}
+	return len(b), nil
+}
+
+// copy reads src into dst, counting the bytes.
+func (p *Progress) copy(dst io.Writer, src io.Reader) error {
+	if p != nil {
+		dst = io.MultiWriter(dst, p)
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+// String describes the progress, e.g. "reading big.json… 1.2 GiB of
+// 2.6 GiB (46%)".
+func (p *Progress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := fmt.Sprintf("reading %s… %s", p.name, FormatBytes(p.read))
+	if p.size > 0 {
+		s += fmt.Sprintf(" of %s (%d%%)", FormatBytes(p.size), min(p.read*100/p.size, 100))
+	}
+	return s
+}
+
+// Show writes the progress to w on one line while the input loads, if it
+// takes long enough to notice. The returned function stops it and clears
+// the line.
+func (p *Progress) Show(w io.Writer) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-done:
+			return
+		case <-time.After(_progressDelay):
+		}
+		t := time.NewTicker(_progressInterval)
+		defer t.Stop()
+		for {
+			fmt.Fprintf(w, "\r\x1b[K%s", p)
+			select {
+			case <-done:
+				fmt.Fprint(w, "\r\x1b[K")
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// fileSize returns the size of f if it is a regular file, and 0 otherwise.
+func fileSize(f *os.File) int64 {
+	if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+		return fi.Size()
+	}
+	return 0
+}
+
+// FormatBytes formats n bytes in binary units, such as 1.5 KiB.
+func FormatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for n/div >= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,90 @@
+package input
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlToJSON converts a TOML document to an indented JSON text, keeping
+// the order of its keys. Dates and times become RFC 3339 strings.
+func tomlToJSON(src string) (string, error) {
+	var v map[string]any
+	md, err := toml.Decode(src, &v)
+	if err != nil {
+		return "", err
+	}
+	order := map[string]int{}
+	for i, k := range md.Keys() {
+		if _, ok := order[k.String()]; !ok {
+			order[k.String()] = i
+		}
+	}
+	var sb strings.Builder
+	writeJSON(&sb, tomlValue(v, nil, order), "")
+	sb.WriteByte('\n')
+	return sb.String(), nil
+}
+
+// tomlValue converts a decoded TOML value at path to the types writeJSON
+// takes. order holds the position of every key in the document.
+func tomlValue(v any, path toml.Key, order map[string]int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		pos := func(k string) int {
+			if i, ok := order[append(slices.Clip(path), k).String()]; ok {
+				return i
+			}
+			return math.MaxInt
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return cmp.Or(cmp.Compare(pos(a), pos(b)), strings.Compare(a, b))
+		})
+		om := make(orderedMap, 0, len(v))
+		for _, k := range keys {
+			om = append(om, mapEntry{k, tomlValue(v[k], append(slices.Clip(path), k), order)})
+		}
+		return om
+	case []map[string]any:
+		arr := make([]any, len(v))
+		for i, x := range v {
+			arr[i] = tomlValue(x, path, order)
+		}
+		return arr
+	case []any:
+		arr := make([]any, len(v))
+		for i, x := range v {
+			arr[i] = tomlValue(x, path, order)
+		}
+		return arr
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64))
+	case time.Time:
+		// The decoder marks local dates and times with these zones.
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format(time.DateOnly)
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
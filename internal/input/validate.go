@@ -0,0 +1,118 @@
+package input
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// _snippetWidth is how much of the line of a syntax error is shown around
+// it.
+const _snippetWidth = 72
+
+// inputError is a syntax error in the input, located by line and column.
+type inputError struct {
+	name      string
+	line, col int
+	text      string
+	err       error
+}
+
+func (e *inputError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.name, e.line, e.col, e.err)
+}
+
+// snippet shows the line of the error with a caret under its column.
+func (e *inputError) snippet() string {
+	rs := []rune(e.text)
+	col := e.col - 1
+	start := max(min(col-_snippetWidth/2, len(rs)-_snippetWidth), 0)
+	end := min(start+_snippetWidth, len(rs))
+	text := string(rs[start:end])
+	if start > 0 {
+		text, col = "…"+text, col-start+1
+	}
+	if end < len(rs) {
+		text += "…"
+	}
+	gutter := fmt.Sprintf("%d | ", e.line)
+	return fmt.Sprintf("%s%s\n%s%s^", gutter, text,
+		strings.Repeat(" ", len(gutter)-2)+"| ", strings.Repeat(" ", max(col, 0)))
+}
+
+// Scan reads content as a stream of JSON values, as jq does, in a single
+// pass. It returns the number of values up to the first that does not
+// parse, and an *inputError for that one, or nil if they all do.
+func Scan(name, content string) (records int, err error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	for {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err == nil {
+			records++
+			continue
+		}
+		offset := len(content)
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			// The offset is just past the character in error.
+			offset = max(int(syntax.Offset)-1, 0)
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = errors.New("unexpected end of input")
+		}
+		lineStart := strings.LastIndexByte(content[:offset], '\n') + 1
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += offset
+		}
+		return records, &inputError{
+			name: name,
+			line: strings.Count(content[:offset], "\n") + 1,
+			col:  utf8.RuneCountInString(content[lineStart:offset]) + 1,
+			text: strings.TrimRight(content[lineStart:lineEnd], "\r"),
+			err:  err,
+		}
+	}
+}
+
+// RawInput reports whether jq is told not to parse its input as JSON.
+func RawInput(jqArgs []string) bool {
+	for _, arg := range jqArgs {
+		switch {
+		case arg == "--raw-input" || arg == "--seq":
+			return true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "R"):
+			return true
+		}
+	}
+	return false
+}
+
+// ConfirmInvalid shows err on the terminal and asks whether to start
+// anyway, as jq may still read the input, for example with NaN in it.
+func ConfirmInvalid(tty io.Writer, err error) bool {
+	fmt.Fprintf(tty, "ijq: input is not valid JSON: %v\n", err)
+	if e, ok := err.(*inputError); ok {
+		fmt.Fprintf(tty, "%s\n", e.snippet())
+	}
+	in, openErr := os.Open("/dev/tty")
+	if openErr != nil {
+		return false
+	}
+	defer in.Close()
+	fmt.Fprint(tty, "Start anyway? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,47 @@
+package input
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		records   int
+		line, col int
+		snippet   string
+	}{
+		{"empty", "", 0, 0, 0, ""},
+		{"one value", `{"a":[1,2]}`, 1, 0, 0, ""},
+		{"json lines", "{\"a\":1}\n{\"a\":2}\n3\n", 3, 0, 0, ""},
+		{"syntax error", "{\"a\":1}\n{\"a\" 2}\n{}\n", 1, 2, 6, "2 | {\"a\" 2}\n  |      ^"},
+		{"truncated", "[1,\n2", 0, 2, 2, "2 | 2\n  |  ^"},
+		{"column in runes", "\"é\" x", 1, 1, 5, "1 | \"é\" x\n  |     ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := Scan("in", tt.content)
+			if records != tt.records {
+				t.Errorf("Scan(%q) records = %d, want %d", tt.content, records, tt.records)
+			}
+			if tt.line == 0 {
+				if err != nil {
+					t.Errorf("Scan(%q): %v", tt.content, err)
+				}
+				return
+			}
+			var e *inputError
+			if !errors.As(err, &e) {
+				t.Fatalf("Scan(%q) error = %v, want an *inputError", tt.content, err)
+			}
+			if e.line != tt.line || e.col != tt.col {
+				t.Errorf("Scan(%q) error at %d:%d, want %d:%d", tt.content, e.line, e.col, tt.line, tt.col)
+			}
+			if got := e.snippet(); got != tt.snippet {
+				t.Errorf("Scan(%q) snippet = %q, want %q", tt.content, got, tt.snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package input
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlToJSON converts an XML document to JSON the way xmltodict does: an
+// element becomes an object of its attributes, keyed with attrPrefix, and
+// children, with its text under textKey. Elements with text only become
+// strings, empty ones null, and repeated children arrays.
+func xmlToJSON(src, attrPrefix, textKey string) (string, error) {
+	type element struct {
+		name     string
+		children orderedMap
+		text     strings.Builder
+	}
+	dec := xml.NewDecoder(strings.NewReader(src))
+	dec.CharsetReader = charsetReader
+	root := &element{}
+	stack := []*element{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		top := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: tok.Name.Local}
+			for _, a := range tok.Attr {
+				name := a.Name.Local
+				// Other prefixes are resolved to namespace URLs, but these
+				// declare them.
+				if a.Name.Space == "xmlns" {
+					name = "xmlns:" + name
+				}
+				e.children.set(attrPrefix+name, a.Value)
+			}
+			stack = append(stack, e)
+		case xml.CharData:
+			top.text.Write(tok)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			var v any
+			text := strings.TrimSpace(top.text.String())
+			switch {
+			case len(top.children) > 0:
+				if text != "" {
+					top.children.set(textKey, text)
+				}
+				v = top.children
+			case text != "":
+				v = text
+			}
+			parent := stack[len(stack)-1]
+			i := parent.children.index(top.name)
+			switch {
+			case i < 0:
+				parent.children.set(top.name, v)
+			case isRepeated(parent.children[i].value):
+				parent.children[i].value = append(parent.children[i].value.([]any), v)
+			default:
+				parent.children[i].value = []any{parent.children[i].value, v}
+			}
+		}
+	}
+	if len(root.children) == 0 {
+		return "", errors.New("no root element")
+	}
+	var sb strings.Builder
+	writeJSON(&sb, root.children, "")
+	sb.WriteByte('\n')
+	return sb.String(), nil
+}
+
+// isRepeated reports whether v holds the values of a repeated element,
+// since element values are never arrays otherwise.
+func isRepeated(v any) bool {
+	_, ok := v.([]any)
+	return ok
+}
+
+// charsetReader decodes the Latin-1 documents encoding/xml rejects, next to
+// the UTF-8 it reads by itself.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "iso-8859-1", "latin1", "latin-1":
+		b, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		rs := make([]rune, len(b))
+		for i, c := range b {
+			rs[i] = rune(c)
+		}
+		return strings.NewReader(string(rs)), nil
+	case "us-ascii", "ascii":
+		return input, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", label)
+}
@@ -0,0 +1,79 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"text", "<a>x</a>", `{"a":"x"}`},
+		{"empty", "<a/>", `{"a":null}`},
+		{"whitespace only", "<a>\n  </a>", `{"a":null}`},
+		{"attributes", `<a id="1" k="v">t</a>`, `{"a":{"@id":"1","@k":"v","#text":"t"}}`},
+		{"attributes only", `<a id="1"/>`, `{"a":{"@id":"1"}}`},
+		{"children", "<a><b>1</b><c>2</c></a>", `{"a":{"b":"1","c":"2"}}`},
+		{"attributes and children", `<a id="1"><b>2</b></a>`, `{"a":{"@id":"1","b":"2"}}`},
+		{"text and children", "<a>t<b>1</b></a>", `{"a":{"b":"1","#text":"t"}}`},
+		{"repeated", "<a><b>1</b><b>2</b><b>3</b></a>", `{"a":{"b":["1","2","3"]}}`},
+		{"repeated empty", "<a><b/><b/></a>", `{"a":{"b":[null,null]}}`},
+		{"repeated with attributes", `<a><b x="1"/><b x="2"/></a>`, `{"a":{"b":[{"@x":"1"},{"@x":"2"}]}}`},
+		{"repeated apart", "<a><b>1</b><c/><b>2</b></a>", `{"a":{"b":["1","2"],"c":null}}`},
+		{"repeated nested", "<a><b><c>1</c></b><b><c>2</c><c>3</c></b></a>", `{"a":{"b":[{"c":"1"},{"c":["2","3"]}]}}`},
+		{"namespaces", `<a xmlns:x="urn:x" x:y="1"><x:b>2</x:b></a>`, `{"a":{"@xmlns:x":"urn:x","@y":"1","b":"2"}}`},
+		{"default namespace", `<a xmlns="urn:a"/>`, `{"a":{"@xmlns":"urn:a"}}`},
+		{"cdata and entities", "<a><![CDATA[<x>]]> &amp; &#233;</a>", `{"a":"\u003cx\u003e \u0026 é"}`},
+		{"prolog and comments", "<?xml version=\"1.0\"?>\n<!-- c --><a><!-- d -->x</a>", `{"a":"x"}`},
+		{"latin-1", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a>caf\xe9</a>", `{"a":"café"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := xmlToJSON(tt.src, "@", "#text")
+			if err != nil {
+				t.Fatalf("xmlToJSON(%q): %v", tt.src, err)
+			}
+			if got := compactJSON(t, out); strings.Join(got, "\n") != tt.want {
+				t.Errorf("xmlToJSON(%q) = %s, want %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLToJSONKeys(t *testing.T) {
+	out, err := xmlToJSON(`<a id="1">t</a>`, "-", "_text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := compactJSON(t, out), `{"a":{"-id":"1","_text":"t"}}`; len(got) != 1 || got[0] != want {
+		t.Errorf("xmlToJSON with prefix - and text key _text = %s, want %s", got, want)
+	}
+}
+
+func TestXMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"empty", "", "no root element"},
+		{"comment only", "<!-- c -->", "no root element"},
+		{"unclosed", "<a><b>", "unexpected EOF"},
+		{"mismatched", "<a></b>", "element <a> closed by </b>"},
+		{"unsupported encoding", `<?xml version="1.0" encoding="EBCDIC"?><a/>`, `unsupported encoding "EBCDIC"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := xmlToJSON(tt.src, "@", "#text")
+			if err == nil {
+				t.Fatalf("xmlToJSON(%q) = %q, want an error", tt.src, out)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("xmlToJSON(%q) error %q, want it to contain %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// _maxAliasNodes caps the nodes that aliases may expand to, so that a
+// small document of nested aliases cannot expand to gigabytes of JSON.
+const _maxAliasNodes = 1 << 20
+
+// yamlToJSON converts every document of src to an indented JSON text.
+func yamlToJSON(src string) (string, error) {
+	docs, err := yamlDocuments(src)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	c := &yamlConverter{anchors: map[*yaml.Node]converted{}}
+	for _, doc := range docs {
+		v, _, err := c.value(doc)
+		if err != nil {
+			return "", err
+		}
+		writeJSON(&sb, v, "")
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// yamlDocuments returns the root node of every document in src.
+func yamlDocuments(src string) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, doc.Content[0])
+		}
+	}
+}
+
+// converted is a node converted to the types writeJSON takes, with the
+// number of nodes it holds. done is unset while the node is converted.
+type converted struct {
+	v     any
+	nodes int
+	done  bool
+}
+
+// yamlConverter converts nodes, counting the nodes aliases expand to.
+type yamlConverter struct {
+	anchors    map[*yaml.Node]converted
+	aliasNodes int
+}
+
+// value converts n and returns the number of nodes in it.
+func (c *yamlConverter) value(n *yaml.Node) (any, int, error) {
+	switch {
+	case n.Kind == yaml.AliasNode:
+		a := c.anchors[n.Alias]
+		if !a.done {
+			return nil, 0, fmt.Errorf("yaml: line %d: alias *%s is inside its own anchor", n.Line, n.Value)
+		}
+		if c.aliasNodes += a.nodes; c.aliasNodes > _maxAliasNodes {
+			return nil, 0, errors.New("yaml: document contains excessive aliasing")
+		}
+		return a.v, a.nodes, nil
+	case n.Anchor != "":
+		c.anchors[n] = converted{}
+		v, nodes, err := c.node(n)
+		c.anchors[n] = converted{v, nodes, true}
+		return v, nodes, err
+	}
+	return c.node(n)
+}
+
+// node converts n, which is not an alias.
+func (c *yamlConverter) node(n *yaml.Node) (any, int, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		om := make(orderedMap, 0, len(n.Content)/2)
+		nodes := 1
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			var key any
+			merge := k.Kind == yaml.ScalarNode && k.ShortTag() == "!!merge"
+			if !merge {
+				var err error
+				if key, _, err = c.value(k); err != nil {
+					return nil, 0, err
+				}
+			}
+			value, size, err := c.value(v)
+			if err != nil {
+				return nil, 0, err
+			}
+			nodes += size
+			if merge {
+				mergeKeys(&om, value)
+			} else {
+				om.set(binaryKey(key), value)
+			}
+		}
+		return om, nodes, nil
+	case yaml.SequenceNode:
+		arr := make([]any, 0, len(n.Content))
+		nodes := 1
+		for _, e := range n.Content {
+			v, size, err := c.value(e)
+			if err != nil {
+				return nil, 0, err
+			}
+			arr = append(arr, v)
+			nodes += size
+		}
+		return arr, nodes, nil
+	}
+	v, err := yamlScalar(n)
+	return v, 1, err
+}
+
+// mergeKeys adds the entries of the mappings in v that om does not have
+// yet, as the << merge key does.
+func mergeKeys(om *orderedMap, v any) {
+	maps, ok := v.([]any)
+	if !ok {
+		maps = []any{v}
+	}
+	for _, m := range maps {
+		src, _ := m.(orderedMap)
+		for _, e := range src {
+			if om.index(e.key) < 0 {
+				*om = append(*om, e)
+			}
+		}
+	}
+}
+
+// yamlScalar resolves a scalar node, as the YAML core schema does. Values
+// yaml.v3 resolves to timestamps and scalars with tags it does not know
+// stay strings.
+func yamlScalar(n *yaml.Node) (any, error) {
+	switch n.ShortTag() {
+	case "!!str":
+		return n.Value, nil
+	case "!!null":
+		return nil, nil
+	case "!!binary":
+		return strings.Join(strings.Fields(n.Value), ""), nil
+	case "!!bool", "!!int", "!!float":
+	default:
+		return n.Value, nil
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case int:
+		return json.Number(strconv.Itoa(v)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return uintValue(v), nil
+	case float64:
+		return floatValue(v), nil
+	}
+	return v, nil
+}
+
+// isYAMLCollections reports whether src parses as YAML with a mapping or
+// sequence in every document.
+func isYAMLCollections(src string) bool {
+	docs, err := yamlDocuments(src)
+	if err != nil {
+		return false
+	}
+	for _, doc := range docs {
+		if doc.Kind != yaml.MappingNode && doc.Kind != yaml.SequenceNode {
+			return false
+		}
+	}
+	return true
+}
+
+// JSONToYAML converts a stream of JSON values to YAML documents separated
+// by ---, keeping the order of object keys.
+func JSONToYAML(src string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(src))
+	dec.UseNumber()
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	for {
+		v, err := decodeOrdered(dec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := enc.Encode(yamlNode(v)); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// yamlNode returns the YAML node for a value decoded by decodeOrdered.
+func yamlNode(v any) *yaml.Node {
+	switch v := v.(type) {
+	case orderedMap:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if len(v) == 0 {
+			n.Style = yaml.FlowStyle
+		}
+		for _, e := range v {
+			n.Content = append(n.Content, yamlNode(e.key), yamlNode(e.value))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if len(v) == 0 {
+			n.Style = yaml.FlowStyle
+		}
+		for _, x := range v {
+			n.Content = append(n.Content, yamlNode(x))
+		}
+		return n
+	case string:
+		n := &yaml.Node{}
+		n.SetString(v)
+		return n
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
@@ -0,0 +1,174 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// compactJSON returns the JSON texts in s compacted, one per document.
+func compactJSON(t *testing.T, s string) []string {
+	t.Helper()
+	var docs []string
+	dec := json.NewDecoder(strings.NewReader(s))
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			t.Fatalf("invalid JSON %q: %v", s, err)
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, buf.String())
+	}
+	return docs
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"mapping", "a: 1\nb: two", []string{`{"a":1,"b":"two"}`}},
+		{"key order", "b: 1\na: 2", []string{`{"b":1,"a":2}`}},
+		{"nested mapping", "a:\n  b:\n    c: d", []string{`{"a":{"b":{"c":"d"}}}`}},
+		{"sequence", "- 1\n- x\n-\n  - y", []string{`[1,"x",["y"]]`}},
+		{"sequence at key indent", "a:\n- 1\n- 2\nb: 3", []string{`{"a":[1,2],"b":3}`}},
+		{"mapping in sequence", "- a: 1\n  b: 2\n- c: 3", []string{`[{"a":1,"b":2},{"c":3}]`}},
+		{"flow", "a: [1, {b: c}, 'd']\ne: {}", []string{`{"a":[1,{"b":"c"},"d"],"e":{}}`}},
+		{"flow over lines", "a: [1,\n  2]", []string{`{"a":[1,2]}`}},
+		{"double quoted", `a: "x\ty\u00e9"`, []string{`{"a":"x\tyé"}`}},
+		{"single quoted", "a: 'it''s'", []string{`{"a":"it's"}`}},
+		{"quoted colon", "a: 'b: c'", []string{`{"a":"b: c"}`}},
+		{"url", "a: http://x.org/y", []string{`{"a":"http://x.org/y"}`}},
+		{"plain over lines", "a: one\n  two\nb: 1", []string{`{"a":"one two","b":1}`}},
+		{"plain over lines in sequence", "- a\n  b", []string{`["a b"]`}},
+		{"dash in plain over lines", "a:\n  - 1\n   - 2", []string{`{"a":["1 - 2"]}`}},
+		{"scalars", "a: ~\nb: true\nc: False\nd: -12\ne: 1.5e3\nf: 0x1f\ng: 0o17\nh: .5\ni: 1_000",
+			[]string{`{"a":null,"b":true,"c":false,"d":-12,"e":1500,"f":31,"g":15,"h":0.5,"i":1000}`}},
+		{"str tag", "a: !!str 123", []string{`{"a":"123"}`}},
+		{"comments", "# top\na: 1 # one\n# between\nb: 'x # y' # two", []string{`{"a":1,"b":"x # y"}`}},
+		{"literal", "a: |\n  one\n   two\n\nb: 1", []string{`{"a":"one\n two\n","b":1}`}},
+		{"literal strip", "a: |-\n  one\n  two", []string{`{"a":"one\ntwo"}`}},
+		{"literal keep", "a: |+\n  one\n\nb: 1", []string{`{"a":"one\n\n","b":1}`}},
+		{"folded", "a: >\n  one\n  two\n\n  three\n", []string{`{"a":"one two\nthree\n"}`}},
+		{"folded at end of input", "a: >\n  one\n  two", []string{`{"a":"one two"}`}},
+		{"anchor and alias", "a: &x\n  b: 1\nc: *x", []string{`{"a":{"b":1},"c":{"b":1}}`}},
+		{"nested aliases", "a: &a [1]\nb: &b [*a, *a]\nc: [*b]", []string{`{"a":[1],"b":[[1],[1]],"c":[[[1],[1]]]}`}},
+		{"merge key", "base: &b\n  x: 1\n  y: 2\nd:\n  <<: *b\n  y: 3", []string{`{"base":{"x":1,"y":2},"d":{"x":1,"y":3}}`}},
+		{"documents", "a: 1\n---\nb: 2\n...\n--- 3\n---", []string{`{"a":1}`, `{"b":2}`, `3`, `null`}},
+		{"directive", "%YAML 1.1\n---\na: 1", []string{`{"a":1}`}},
+		{"scalar document", "hello", []string{`"hello"`}},
+		{"empty", "# nothing\n", nil},
+		{"crlf", "a: 1\r\nb: 2\r\n", []string{`{"a":1,"b":2}`}},
+		{"null key", "~: 1", []string{`{"null":1}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := yamlToJSON(tt.src)
+			if err != nil {
+				t.Fatalf("yamlToJSON(%q): %v", tt.src, err)
+			}
+			got := compactJSON(t, out)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("yamlToJSON(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// billionLaughs nests aliases so that each level doubles the nodes of the
+// one before.
+var billionLaughs = func() string {
+	var sb strings.Builder
+	sb.WriteString("a0: &a0 [x, x]\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&sb, "a%d: &a%d [*a%d, *a%d]\n", i, i, i-1, i-1)
+	}
+	return sb.String()
+}()
+
+func TestYAMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"mapping value in plain scalar", "a: b: c", "mapping values are not allowed"},
+		{"mapping value in sequence", "- a: b: c", "mapping values are not allowed"},
+		{"bad mapping indentation", "a:\n    b: 1\n  c: 2", "did not find expected key"},
+		{"content after sequence", "- a\nb: 1", "did not find expected '-' indicator"},
+		{"unterminated string", `a: "x`, "found unexpected end of stream"},
+		{"content after quoted string", `a: "x" y`, "did not find expected key"},
+		{"unknown anchor", "a: *nope", "unknown anchor 'nope'"},
+		{"unknown anchor in flow", "a: [*nope]", "unknown anchor 'nope'"},
+		{"bad block scalar header", "a: |x\n  y", "did not find expected comment or line break"},
+		{"unclosed flow", "a: [1, 2", "did not find expected ',' or ']'"},
+		{"flow missing comma", "a: [1 2}", "did not find expected ',' or ']'"},
+		{"content after flow", "a: [1] x", "did not find expected key"},
+		{"alias inside its own anchor", "a: &x [*x]", "alias *x is inside its own anchor"},
+		{"excessive aliasing", billionLaughs, "excessive aliasing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := yamlToJSON(tt.src)
+			if err == nil {
+				t.Fatalf("yamlToJSON(%q) = %q, want an error", tt.src, out)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("yamlToJSON(%q) error %q, want it to contain %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"object", `{"b":1,"a":"x"}`, "b: 1\na: x\n"},
+		{"nested", `{"a":{"b":[1,{"c":null}]}}`, "a:\n  b:\n    - 1\n    - c: null\n"},
+		{"empty collections", `{"a":[],"b":{}}`, "a: []\nb: {}\n"},
+		{"strings that need quotes", `["true","1","a: b","","- x"]`, "- \"true\"\n- \"1\"\n- 'a: b'\n- \"\"\n- '- x'\n"},
+		{"documents", "1 2", "1\n---\n2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONToYAML(tt.src)
+			if err != nil {
+				t.Fatalf("JSONToYAML(%q): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONToYAML(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestYAMLRoundTrip checks that JSON converted to YAML reads back the same.
+func TestYAMLRoundTrip(t *testing.T) {
+	for _, src := range []string{
+		`{"a":1,"b":[true,false,null],"c":{"d":"e f","g":[]}}`,
+		`["multi\nline\n","  padded","#hash","x: y","~","0x10","1e3",-2.5]`,
+		`[[1,2],[{"a":[{"b":{}}]}]]`,
+		`{"":"empty key","with space":"v","quote\"d":"'"}`,
+	} {
+		y, err := JSONToYAML(src)
+		if err != nil {
+			t.Fatalf("JSONToYAML(%q): %v", src, err)
+		}
+		out, err := yamlToJSON(y)
+		if err != nil {
+			t.Fatalf("yamlToJSON(%q): %v", y, err)
+		}
+		if got := compactJSON(t, out); len(got) != 1 || got[0] != src {
+			t.Errorf("round trip of %s through %q = %q", src, y, got)
+		}
+	}
+}
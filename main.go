@@ -1,226 +1,496 @@
 package main
 
 import (
-	"cmp"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
-	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/bubbles/help"
-	"github.com/charmbracelet/bubbles/key"
-	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbles/viewport"
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/input"
+	"github.com/maolonglong/ijq/tui"
 	"github.com/muesli/termenv"
 )
 
-var _marginTop1 = lipgloss.NewStyle().MarginTop(1)
-
-type keyMap struct {
-	quit          key.Binding
-	focusNextPane key.Binding
-	eval          key.Binding
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [filter] [file|url...] [-- jq-args...]\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprint(os.Stderr, `  --arg name value
+    	bind $name to the string value
+  --argjson name text
+    	bind $name to the JSON text
+  --rawfile name file
+    	bind $name to the contents of the file
+  --slurpfile name file
+    	bind $name to an array of the JSON texts in the file
+`)
 }
 
-func defaultKeyMap() keyMap {
-	return keyMap{
-		quit: key.NewBinding(
-			key.WithKeys("ctrl+c"),
-			key.WithHelp("ctrl+c", "quit"),
-		),
-		focusNextPane: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "focus next pane"),
-		),
-		eval: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "eval"),
-		),
+// splitJQArgs separates ijq's own arguments from those after "--", which are
+// passed to every jq invocation.
+func splitJQArgs(args []string) (own, jq []string) {
+	if i := slices.Index(args, "--"); i >= 0 {
+		return args[:i], args[i+1:]
 	}
+	return args, nil
 }
 
-func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.quit, k.eval, k.focusNextPane}
-}
-
-func (k keyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{{k.quit, k.eval, k.focusNextPane}}
-}
-
-type model struct {
-	help          help.Model
-	content       string
-	result        string
-	viewport      viewport.Model
-	keys          keyMap
-	textinput     textinput.Model
-	ready         bool
-	focusViewport bool
-}
-
-func newModel(content string) model {
-	ti := textinput.New()
-	ti.Focus()
-	ti.Placeholder = "jq filter"
-
-	return model{
-		content:   content,
-		result:    jq(content, "."),
-		keys:      defaultKeyMap(),
-		textinput: ti,
-		help:      help.New(),
+// initialFilter splits the positional arguments into the initial filter and
+// the input files. The filter comes from filterFile if set, or else from the
+// first argument unless that names an existing file.
+func initialFilter(args []string, filterFile string) (string, []string, error) {
+	if filterFile != "" {
+		b, err := os.ReadFile(filterFile)
+		if err != nil {
+			return "", nil, err
+		}
+		return strings.TrimRight(string(b), "\n"), args, nil
+	}
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	if _, err := os.Stat(args[0]); err == nil || input.IsURL(args[0]) {
+		return "", args, nil
 	}
+	return args[0], args[1:], nil
 }
 
-func (m model) Init() tea.Cmd {
-	return nil
-}
+// _exitCancel is the exit status when the user quits without accepting.
+const _exitCancel = 130
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		inputHeight := lipgloss.Height(m.textinput.View())
-		helpHeight := lipgloss.Height(_marginTop1.Render(m.help.View(m.keys)))
-		margin := inputHeight + helpHeight
-		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-margin)
-			m.viewport.HighPerformanceRendering = false
-			m.viewport.SetContent(m.result)
-			m.ready = true
-		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - margin
-		}
-
-		m.textinput.Width = msg.Width
-		m.help.Width = msg.Width
-
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
-		case "tab":
-			if !m.focusViewport {
-				m.textinput.Blur()
-				m.keys.eval.SetEnabled(false)
-			} else {
-				m.textinput.Focus()
-				m.keys.eval.SetEnabled(true)
-			}
-			m.focusViewport = !m.focusViewport
-		case "enter":
-			if !m.focusViewport {
-				out := jq(m.content, m.jqFilter())
-				m.result = out
-				m.viewport.SetContent(out)
-				m.viewport.GotoTop()
-			}
-		default:
-			if !m.focusViewport {
-				m.textinput, cmd = m.textinput.Update(msg)
-			} else {
-				m.viewport, cmd = m.viewport.Update(msg)
-			}
+// printResult writes the accepted filter and/or its result to w, according
+// to the output mode, printing what jq printed to stderr for the result
+// to stderr.
+func printResult(w io.Writer, res tui.Result) error {
+	mode := res.Options.OutputMode
+	if mode == tui.OutputFilter || mode == tui.OutputBoth {
+		if _, err := fmt.Fprintln(w, res.Filter); err != nil {
+			return err
 		}
-
-	default:
 	}
-
-	return m, cmd
-}
-
-func (m model) View() string {
-	var sb strings.Builder
-	sb.WriteString(m.textinput.View())
-	sb.WriteByte('\n')
-	sb.WriteString(m.viewport.View())
-	sb.WriteByte('\n')
-	sb.WriteString(_marginTop1.Render(m.help.View(m.keys)))
-	return sb.String()
-}
-
-func (m model) jqFilter() string {
-	return cmp.Or(strings.TrimSpace(m.textinput.Value()), ".")
-}
-
-func jq(content, filter string) string {
-	cmd := exec.Command("jq", "--color-output", cmp.Or(filter, "."))
-	cmd.Stdin = strings.NewReader(content)
-	var sb strings.Builder
-	cmd.Stdout = &sb
-	cmd.Stderr = &sb
-	_ = cmd.Run()
-	return sb.String()
+	if mode == tui.OutputResult || mode == tui.OutputBoth {
+		if _, err := io.WriteString(w, res.Output); err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, res.Errors)
+	}
+	return nil
 }
 
-func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s [file...]\n", os.Args[0])
+// openTTY opens the controlling terminal for drawing the interface, so that
+// stdout is left for the final output even if stderr is redirected too. It
+// falls back to stderr when there is no terminal to open.
+func openTTY() (*os.File, func()) {
+	f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return os.Stderr, func() {}
+	}
+	return f, func() { f.Close() }
 }
 
-func getContent() (string, error) {
+func main() {
 	var (
-		sb  strings.Builder
-		err error
+		engineName  string
+		configFile  string
+		sessionName string
+		filterFile  string
+		noHistory   bool
+		inputOpts   = input.Options{Format: input.FormatAuto, XMLAttrPrefix: "@", XMLTextKey: "#text"}
+		delimiter   string
+		watch       bool
+		concat      bool
+		noValidate  bool
+		follow      bool
+		command     string
+		interval    time.Duration
+		httpOpts    = input.HTTPOptions{Header: http.Header{}}
+		outputFmt   string
+		historySize int
+		keyPreset   string
+		showVersion bool
+		redactKeys  string
+		colorMode   string
+		themeName   string
+		jqPath      string
+		paste       bool
+		// nullImplied is set if null input was used for lack of any.
+		nullImplied bool
 	)
-	if flag.NArg() == 0 {
-		_, err = io.Copy(&sb, os.Stdin)
-	} else {
-		for _, name := range flag.Args() {
-			if err = readFile(&sb, name); err != nil {
-				break
-			}
+	var opts tui.Options
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.BoolVar(&showVersion, "v", false, "print the version of ijq and of the jq it runs, and exit")
+	flag.BoolVar(&showVersion, "version", false, "same as -v")
+	flag.StringVar(&keyPreset, "keys", tui.KeysDefault, "key binding `preset`: default or vim")
+	flag.StringVar(&configFile, "config", "", "read default flags from `file` (default $XDG_CONFIG_HOME/ijq/config.toml)")
+	flag.StringVar(&opts.LogResults, "log-results", "", "append timestamped filter/result snapshots to `path`")
+	flag.StringVar(&engineName, "engine", "", "evaluation `engine`: jq, gojq, or a jq-compatible binary such as jaq (default jq, falling back to gojq if jq is not installed)")
+	flag.StringVar(&jqPath, "jq-path", "", "jq binary to run, such as /opt/homebrew/bin/jq-1.7 (default jq in $PATH; also IJQ_JQ)")
+	flag.BoolVar(&noHistory, "no-history", false, "do not read or write the history file")
+	flag.BoolVar(&opts.NoMouse, "no-mouse", false, "leave the mouse to the terminal, for its own text selection")
+	flag.BoolVar(&opts.NoScrollbar, "no-scrollbar", false, "hide the scrollbar next to a result taller than the screen")
+	flag.StringVar(&sessionName, "session", "", "restore the filter, toggles and input of session `name`, and save them on exit")
+	flag.IntVar(&historySize, "history-size", tui.DefaultHistorySize, "maximum number of filters kept in the history file")
+	flag.StringVar(&filterFile, "f", "", "read the initial filter from `file`")
+	flag.StringVar(&filterFile, "from-file", "", "same as -f")
+	flag.StringVar(&opts.OutputMode, "output-mode", tui.OutputFilter, "what to print on exit: filter, result, or both")
+	flag.StringVar(&inputOpts.Format, "input", input.FormatAuto, "input `format`: auto, json, jsonc, ndjson, yaml, toml, csv, tsv, xml, msgpack or cbor")
+	flag.StringVar(&delimiter, "delimiter", "", "field separator `char` of CSV and TSV input (default , or tab)")
+	flag.StringVar(&inputOpts.XMLAttrPrefix, "xml-attr-prefix", inputOpts.XMLAttrPrefix, "`prefix` of the keys of XML attributes")
+	flag.StringVar(&inputOpts.XMLTextKey, "xml-text-key", inputOpts.XMLTextKey, "`key` of the text of XML elements with attributes or children")
+	flag.BoolVar(&inputOpts.NoHeader, "no-header", false, "read CSV and TSV records as arrays instead of objects keyed by the first row")
+	flag.StringVar(&outputFmt, "output", input.FormatJSON, "result `format`: json or yaml (toggle with alt+o)")
+	flag.Func("L", "search `dir` for jq modules, and not jq's default path (repeatable)", func(s string) error {
+		opts.LibPaths = append(opts.LibPaths, s)
+		return nil
+	})
+	flag.Func("header", "add a `header`, such as \"Authorization: Bearer …\", to requests for input URLs (repeatable)", func(s string) error {
+		return input.ParseHeader(httpOpts.Header, s)
+	})
+	flag.DurationVar(&httpOpts.Timeout, "http-timeout", input.DefaultHTTPTimeout, "give up fetching an input URL after `duration`")
+	flag.BoolVar(&concat, "concat", false, "read several input files as a single input, instead of a document for each to switch between with ctrl+↑ and ctrl+↓")
+	flag.BoolVar(&noValidate, "no-validate", false, "start without checking that the input is valid JSON, or asking what to do if it is not")
+	flag.BoolVar(&watch, "watch", false, "reload the input files and evaluate the filter again when they change")
+	flag.StringVar(&command, "exec", "", "run `command` with the shell and read its output as input, again on r")
+	flag.DurationVar(&interval, "interval", 0, "with --exec, run the command again every `duration`")
+	flag.BoolVar(&paste, "paste", false, "read the input from the system clipboard")
+	flag.BoolVar(&follow, "follow", false, "keep reading JSON values appended to the input file or stdin, like tail -f")
+	flag.DurationVar(&opts.Timeout, "timeout", 0, "stop evaluating a filter after `duration`, such as 5s (default no limit)")
+	flag.BoolVar(&opts.Live, "live", false, "re-evaluate the filter automatically as you type")
+	flag.IntVar(&opts.MaxLines, "max-lines", tui.DefaultMaxLines, "show the first `n` lines of the result, and n more on m (0 shows all)")
+	flag.DurationVar(&opts.Debounce, "debounce", tui.DefaultDebounce, "with --live, wait `duration` after the last keystroke before evaluating")
+	flag.Float64Var(&opts.SplitRatio, "split-ratio", tui.DefaultSplit, "width of the left pane of the split view as a `fraction` of the screen (resize with alt+< and alt+>)")
+	flag.BoolVar(&opts.Wrap, "wrap", false, "wrap long lines of the result (toggle with alt+w)")
+	flag.BoolVar(&opts.Redact, "redact", false, "mask the values of secret keys in the result shown, e.g. to share the screen (toggle with alt+h)")
+	flag.StringVar(&redactKeys, "redact-keys", tui.DefaultRedactKeys, "`regexp` matching the keys whose values --redact masks")
+	flag.StringVar(&themeName, "theme", tui.ThemeDefault, "`name` of the color theme: default, dark, or light")
+	flag.StringVar(&colorMode, "color", _colorAuto, "when to use colors: auto, always, or never (auto honors NO_COLOR)")
+	flag.StringVar(&opts.ScrollMode, "scroll", tui.ScrollAuto, "where the result scrolls after an evaluation: auto (keep the place in a similar result), keep, or top")
+	flag.BoolVar(&opts.LineNumbers, "line-numbers", false, "show line numbers next to the result (toggle with alt+n)")
+	flag.BoolVar(&opts.Raw, "r", false, "output raw strings, not JSON texts")
+	flag.BoolVar(&opts.Raw, "raw-output", false, "same as -r")
+	flag.BoolVar(&opts.Compact, "c", false, "compact instead of pretty-printed output")
+	flag.BoolVar(&opts.Compact, "compact-output", false, "same as -c")
+	flag.BoolVar(&opts.SortKeys, "S", false, "sort the keys of objects in the output (toggle with alt+S)")
+	flag.BoolVar(&opts.SortKeys, "sort-keys", false, "same as -S")
+	flag.BoolVar(&opts.Tab, "tab", false, "indent with a tab instead of spaces (cycle the indentation with alt+I)")
+	flag.IntVar(&opts.Indent, "indent", engine.DefaultIndent, "indent by `n` spaces, at most 7 (0 is the same as -c)")
+	flag.BoolVar(&opts.ASCIIOutput, "a", false, "escape the characters outside ASCII in the output")
+	flag.BoolVar(&opts.ASCIIOutput, "ascii-output", false, "same as -a")
+	flag.BoolVar(&opts.Slurp, "s", false, "read all inputs into an array and use it as the single input value")
+	flag.BoolVar(&opts.Slurp, "slurp", false, "same as -s")
+	flag.BoolVar(&opts.Stream, "stream", false, "read the input as [path, leaf] events, for documents too large to parse whole (toggle with alt+m)")
+	flag.BoolVar(&opts.NullInput, "n", false, "use null as the single input value instead of reading stdin")
+	flag.BoolVar(&opts.NullInput, "null-input", false, "same as -n")
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		if err := writeCompletion(os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
+	args, jqArgs := splitJQArgs(os.Args[1:])
+	args, vars, err := engine.ExtractVars(args)
 	if err != nil {
-		return "", err
+		log.Fatal(err)
 	}
-	return sb.String(), nil
-}
-
-func readFile(dst io.Writer, name string) error {
-	f, err := os.Open(name)
+	_ = flag.CommandLine.Parse(args)
+	setKeys := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { setKeys[flagKey(f)] = true })
+	// Environment variables override the config file, and flags both.
+	if err := loadEnv(setKeys); err != nil {
+		log.Fatal(err)
+	}
+	explicitConfig := configFile != ""
+	if !explicitConfig {
+		if configFile, err = configPath(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	bindings, colors, err := loadConfig(configFile, explicitConfig, setKeys)
 	if err != nil {
-		return err
+		log.Fatal(err)
 	}
-	defer f.Close()
-	_, err = io.Copy(dst, f)
-	return err
-}
+	// isSet reports whether a flag was given a value for this run, from
+	// the command line, the environment or the config file.
+	isSet := func(names ...string) bool {
+		return slices.ContainsFunc(names, func(n string) bool { return setKeys[flagKey(flag.Lookup(n))] })
+	}
+	// jq-path may come from the environment or the config file.
+	if showVersion {
+		if err := engine.WriteVersion(os.Stdout, jqPath); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if !slices.Contains(tui.KeyPresets, keyPreset) {
+		log.Fatalf("invalid key preset %q: must be one of %s", keyPreset, strings.Join(tui.KeyPresets, ", "))
+	}
+	keys := tui.DefaultKeyMap()
+	if keyPreset == tui.KeysVim {
+		keys.Vim()
+	}
+	if err := keys.Remap(bindings); err != nil {
+		log.Fatalf("config %s: %v", configFile, err)
+	}
+	opts.Keys = &keys
+	theme, ok := tui.Themes[themeName]
+	if !ok {
+		log.Fatalf("invalid theme %q: must be one of %s", themeName, strings.Join(tui.ThemeNames, ", "))
+	}
+	for part, color := range colors {
+		if err := theme.Set(part, color); err != nil {
+			log.Fatalf("config %s: %v", configFile, err)
+		}
+	}
+	theme.Apply(os.Getenv("JQ_COLORS"))
+	if opts.Indent < 0 || opts.Indent > engine.MaxIndent {
+		log.Fatalf("invalid indent %d: must be between 0 and %d", opts.Indent, engine.MaxIndent)
+	}
+	if opts.Indent == 0 {
+		opts.Compact, opts.Indent = true, engine.DefaultIndent
+	}
+	if opts.RedactKeys, err = regexp.Compile(redactKeys); err != nil {
+		log.Fatalf("invalid --redact-keys: %v", err)
+	}
+	if !slices.Contains(_colorModes, colorMode) {
+		log.Fatalf("invalid color mode %q: must be one of %s", colorMode, strings.Join(_colorModes, ", "))
+	}
+	if !slices.Contains(tui.ScrollModes, opts.ScrollMode) {
+		log.Fatalf("invalid scroll mode %q: must be one of %s", opts.ScrollMode, strings.Join(tui.ScrollModes, ", "))
+	}
+	if !slices.Contains(tui.OutputModes, opts.OutputMode) {
+		log.Fatalf("invalid output mode %q: must be one of %s", opts.OutputMode, strings.Join(tui.OutputModes, ", "))
+	}
+	if !slices.Contains(input.Formats, inputOpts.Format) {
+		log.Fatalf("invalid input format %q: must be one of %s", inputOpts.Format, strings.Join(input.Formats, ", "))
+	}
+	if delimiter != "" {
+		if inputOpts.Delimiter, err = input.ParseDelimiter(delimiter); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if opts.SplitRatio < tui.MinSplit || opts.SplitRatio > tui.MaxSplit {
+		log.Fatalf("invalid --split-ratio %v: must be between %v and %v", opts.SplitRatio, tui.MinSplit, tui.MaxSplit)
+	}
+	if opts.MaxLines < 0 {
+		log.Fatalf("invalid --max-lines %d: must not be negative", opts.MaxLines)
+	}
+	if outputFmt != input.FormatJSON && outputFmt != input.FormatYAML {
+		log.Fatalf("invalid output format %q: must be json or yaml", outputFmt)
+	}
+	opts.OutputYAML = outputFmt == input.FormatYAML
+	opts.Options.Args = jqArgs
+	opts.Options.Vars = vars
 
-func main() {
-	log.SetFlags(0)
-	flag.Usage = usage
-	flag.Parse()
+	var sess *session
+	if sessionName != "" {
+		if sess, err = loadSession(sessionName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if sess != nil {
+		if err := sess.restore(&opts, isSet); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	_, err := exec.LookPath("jq")
+	eng, err := engine.New(engineName, jqPath)
 	if err != nil {
-		log.Fatal("'jq: command not found")
+		log.Fatal(err)
+	}
+	if err := eng.Check(opts.Options); err != nil {
+		log.Fatal(err)
+	}
+	opts.Engine, opts.EngineName, opts.JQPath = eng, engineName, jqPath
+
+	if !noHistory {
+		opts.History, err = tui.OpenHistory(historySize)
+		if err != nil {
+			log.Printf("history disabled: %v", err)
+		}
 	}
 
-	content, err := getContent()
+	filter, files, err := initialFilter(flag.Args(), filterFile)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if sess != nil {
+		if filter == "" && filterFile == "" {
+			filter = sess.Filter
+		}
+		if len(files) == 0 && !opts.NullInput && stdinIsTerminal() {
+			files = sess.Files
+		}
+	}
+	opts.Filter = filter
 
-	lipgloss.SetColorProfile(termenv.NewOutput(os.Stderr).Profile)
-	p := tea.NewProgram(
-		newModel(content),
-		tea.WithOutput(os.Stderr),
-		tea.WithAltScreen(),
-	)
+	tty, closeTTY := openTTY()
+	defer closeTTY()
+
+	if interval != 0 && command == "" {
+		log.Fatal("--interval requires --exec")
+	}
+	if len(files) == 0 && command == "" && !follow && !paste && !opts.NullInput && stdinIsTerminal() {
+		// Reading the terminal would wait, with nothing on screen, for
+		// input the user most likely did not mean to type.
+		opts.NullInput, nullImplied = true, true
+		opts.Notice = "no input piped in; try --paste"
+	}
+	var content string
+	switch {
+	case paste:
+		switch {
+		case len(files) > 0 || opts.NullInput || command != "":
+			log.Fatal("--paste cannot be used with input files, --null-input or --exec")
+		case watch || follow:
+			log.Fatal("--paste cannot be used with --watch or --follow")
+		}
+		if content, err = tui.ReadClipboard(); err != nil {
+			log.Fatal(err)
+		}
+		if content, err = input.Convert(content, nil, inputOpts); err != nil {
+			log.Fatal(err)
+		}
+	case command != "":
+		switch {
+		case len(files) > 0 || opts.NullInput:
+			log.Fatal("--exec cannot be used with input files or --null-input")
+		case watch || follow:
+			log.Fatal("--exec cannot be used with --watch or --follow")
+		}
+		load := func() ([]input.Document, error) {
+			content, err := input.RunCommand(command)
+			if err != nil {
+				return nil, err
+			}
+			content, err = input.Convert(content, nil, inputOpts)
+			return []input.Document{{Name: command, Content: content}}, err
+		}
+		docs, err := load()
+		if err != nil {
+			log.Fatal(err)
+		}
+		content = docs[0].Content
+		opts.Watcher = tui.NewCommandWatcher(interval, load)
+	case follow:
+		switch {
+		case watch:
+			log.Fatal("--follow and --watch cannot be used together")
+		case opts.NullInput:
+			log.Fatal("--follow and --null-input cannot be used together")
+		case len(files) > 1 || len(files) == 1 && input.IsURL(files[0]):
+			log.Fatal("--follow requires a single input file or stdin")
+		case inputOpts.Format != input.FormatAuto && inputOpts.Format != input.FormatJSON && inputOpts.Format != input.FormatNDJSON:
+			log.Fatalf("--follow reads JSON input only, not %s", inputOpts.Format)
+		}
+		if content, opts.InputRecords, opts.Follow, err = tui.OpenFollow(files); err != nil {
+			log.Fatal(err)
+		}
+	case len(files) > 1 && !concat:
+		loading := &input.Progress{}
+		stop := loading.Show(tty)
+		opts.Documents, err = input.LoadDocuments(files, httpOpts, inputOpts, loading)
+		stop()
+		if err != nil {
+			log.Fatal(err)
+		}
+		content = opts.Documents[0].Content
+	default:
+		loading := &input.Progress{}
+		stop := loading.Show(tty)
+		content, err = input.GetContent(files, opts.NullInput, httpOpts, loading)
+		stop()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if content, err = input.Convert(content, files, inputOpts); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !noValidate && command == "" && !follow && !opts.NullInput && !opts.Stream && !input.RawInput(jqArgs) {
+		docs := opts.Documents
+		if docs == nil {
+			name := "stdin"
+			switch {
+			case paste:
+				name = "clipboard"
+			case len(files) == 1:
+				name = files[0]
+			case len(files) > 1:
+				name = "input"
+			}
+			docs = []input.Document{{Name: name, Content: content}}
+		}
+		for i, d := range docs {
+			records, err := input.Scan(d.Name, d.Content)
+			if i == 0 {
+				// Passing the count on spares the interface another
+				// pass over a large input.
+				opts.InputRecords = records
+			}
+			if err != nil {
+				if !input.ConfirmInvalid(tty, err) {
+					os.Exit(1)
+				}
+				break
+			}
+		}
+	}
+	if watch {
+		local := slices.DeleteFunc(slices.Clone(files), input.IsURL)
+		if len(local) == 0 {
+			log.Fatal("--watch requires input files")
+		}
+		opts.Watcher = tui.NewWatcher(local, func() ([]input.Document, error) {
+			if opts.Documents != nil {
+				return input.LoadDocuments(files, httpOpts, inputOpts, nil)
+			}
+			content, err := input.GetContent(files, false, httpOpts, nil)
+			if err != nil {
+				return nil, err
+			}
+			content, err = input.Convert(content, files, inputOpts)
+			return []input.Document{{Content: content}}, err
+		})
+	}
 
-	tm, err := p.Run()
+	profile := colorProfile(colorMode, tty)
+	lipgloss.SetColorProfile(profile)
+	opts.Monochrome = profile == termenv.Ascii
+	opts.Term = tty
+	opts.Input = content
+	res, err := tui.Run(opts)
+	opts.Watcher.Close()
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	m := tm.(model)
-	fmt.Println(m.jqFilter())
+	if sessionName != "" {
+		state := res.Options
+		// The null input stands in for a terminal on stdin this time only,
+		// and would otherwise ignore the input of the next run.
+		state.NullInput = state.NullInput && !nullImplied
+		if err := newSession(state, files).save(sessionName); err != nil {
+			log.Printf("session not saved: %v", err)
+		}
+	}
+	if res.Options.SplitRatio != opts.SplitRatio {
+		if err := saveConfig(configFile, "split-ratio", strconv.FormatFloat(res.Options.SplitRatio, 'f', -1, 64)); err != nil {
+			log.Printf("split ratio not saved: %v", err)
+		}
+	}
+	if !res.Accepted {
+		os.Exit(_exitCancel)
+	}
+	if err := printResult(os.Stdout, res); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(res.ExitCode)
 }
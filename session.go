@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/tui"
+)
+
+// session is the state saved by --session NAME and restored when ijq is
+// started with the same name.
+type session struct {
+	Filter      string   `json:"filter"`
+	Files       []string `json:"files,omitempty"`
+	Vars        []string `json:"vars,omitempty"`
+	Raw         bool     `json:"raw,omitempty"`
+	Compact     bool     `json:"compact,omitempty"`
+	SortKeys    bool     `json:"sort_keys,omitempty"`
+	Tab         bool     `json:"tab,omitempty"`
+	Indent      int      `json:"indent,omitempty"`
+	ASCIIOutput bool     `json:"ascii_output,omitempty"`
+	Slurp       bool     `json:"slurp,omitempty"`
+	NullInput   bool     `json:"null_input,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	Live        bool     `json:"live,omitempty"`
+	LineNumbers bool     `json:"line_numbers,omitempty"`
+	Wrap        bool     `json:"wrap,omitempty"`
+	YOffset     int      `json:"y_offset,omitempty"`
+}
+
+// sessionPath returns the file of session name in
+// $XDG_DATA_HOME/ijq/sessions.
+func sessionPath(name string) (string, error) {
+	if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid session name %q", name)
+	}
+	hist, err := tui.DefaultHistoryPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(hist), "sessions", name+".json"), nil
+}
+
+// loadSession reads session name, returning nil if it was never saved.
+func loadSession(name string) (*session, error) {
+	path, err := sessionPath(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("session %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+func (s *session) save(name string) error {
+	path, err := sessionPath(name)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+// newSession captures opts, as left by the user, which was started on
+// files.
+func newSession(opts tui.Options, files []string) *session {
+	s := &session{
+		Filter:      opts.Filter,
+		Raw:         opts.Raw,
+		Compact:     opts.Compact,
+		SortKeys:    opts.SortKeys,
+		Tab:         opts.Tab,
+		Indent:      opts.Indent,
+		ASCIIOutput: opts.ASCIIOutput,
+		Slurp:       opts.Slurp,
+		NullInput:   opts.NullInput,
+		Stream:      opts.Stream,
+		Live:        opts.Live,
+		LineNumbers: opts.LineNumbers,
+		Wrap:        opts.Wrap,
+		YOffset:     opts.YOffset,
+	}
+	for _, f := range files {
+		if abs, err := filepath.Abs(f); err == nil {
+			f = abs
+		}
+		s.Files = append(s.Files, f)
+	}
+	for _, v := range opts.Vars {
+		s.Vars = append(s.Vars, v.String())
+	}
+	return s
+}
+
+// restore applies the saved toggles and variables to opts, except those
+// that set reports were given for this run, whether on the command line,
+// in the environment or in the config file.
+func (s *session) restore(opts *tui.Options, set func(names ...string) bool) error {
+	restoreBool := func(dst *bool, v bool, names ...string) {
+		if !set(names...) {
+			*dst = v
+		}
+	}
+	restoreBool(&opts.Raw, s.Raw, "r", "raw-output")
+	restoreBool(&opts.Compact, s.Compact, "c", "compact-output")
+	restoreBool(&opts.Slurp, s.Slurp, "s", "slurp")
+	restoreBool(&opts.NullInput, s.NullInput, "n", "null-input")
+	restoreBool(&opts.Stream, s.Stream, "stream")
+	restoreBool(&opts.Live, s.Live, "live")
+	restoreBool(&opts.LineNumbers, s.LineNumbers, "line-numbers")
+	restoreBool(&opts.Wrap, s.Wrap, "wrap")
+	restoreBool(&opts.SortKeys, s.SortKeys, "S", "sort-keys")
+	restoreBool(&opts.Tab, s.Tab, "tab")
+	restoreBool(&opts.ASCIIOutput, s.ASCIIOutput, "a", "ascii-output")
+	if !set("indent") && s.Indent != 0 {
+		opts.Indent = s.Indent
+	}
+	opts.YOffset = s.YOffset
+	if len(opts.Vars) > 0 {
+		return nil
+	}
+	for _, str := range s.Vars {
+		v, err := engine.ParseVariable(str)
+		if err != nil {
+			return err
+		}
+		opts.Vars = append(opts.Vars, v)
+	}
+	return nil
+}
+
+// stdinIsTerminal reports whether nothing is piped into ijq.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// _benchRuns is how many times a benchmark evaluates the filter.
+const _benchRuns = 10
+
+// benchMsg carries the outcome of a finished benchmark.
+type benchMsg struct {
+	filter    string
+	engine    string
+	durations []time.Duration
+	// size is the number of bytes the filter printed.
+	size int
+	err  error
+}
+
+// benchStats sums up a benchmark, to compare the next one with.
+type benchStats struct {
+	filter           string
+	min, median, max time.Duration
+	size             int
+}
+
+// benchmark evaluates the filter _benchRuns times in a row in the
+// background, bypassing the result cache, and reports how long it took.
+func (m *model) benchmark() tea.Cmd {
+	if m.benchmarking {
+		m.setStatus(nil, "benchmark already running")
+		return nil
+	}
+	m.benchmarking = true
+	m.setStatus(nil, "benchmarking %d runs…", _benchRuns)
+	eng, content, filter, opts := m.engine, m.content, m.jqFilter(), m.runOptions()
+	newContext, timeout := m.evalContext, m.timeout
+	return func() tea.Msg {
+		msg := benchMsg{filter: filter, engine: eng.Describe()}
+		for range _benchRuns {
+			ctx, cancel := newContext()
+			opts.Output = &engine.OutputBuffer{Limit: engine.MaxOutput}
+			start := time.Now()
+			res := eng.Eval(ctx, content, filter, opts)
+			d := time.Since(start)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				res = timedOut(timeout)
+			}
+			cancel()
+			if res.ExitCode != 0 {
+				msg.err = errors.New(cmp.Or(strings.TrimSpace(res.Errors), fmt.Sprintf("exit status %d", res.ExitCode)))
+				return msg
+			}
+			msg.durations = append(msg.durations, d)
+			msg.size = len(ansi.Strip(res.Output)) + res.Dropped
+		}
+		return msg
+	}
+}
+
+// benchmarked shows the timings of a benchmark next to those of the one
+// before it.
+func (m *model) benchmarked(msg benchMsg) {
+	m.benchmarking = false
+	if msg.err != nil {
+		m.setStatus(fmt.Errorf("benchmark failed: %w", msg.err), "")
+		return
+	}
+	d := slices.Sorted(slices.Values(msg.durations))
+	n := len(d)
+	s := benchStats{
+		filter: strings.ReplaceAll(cmp.Or(strings.TrimSpace(msg.filter), "."), "\n", " "),
+		min:    d[0],
+		median: (d[(n-1)/2] + d[n/2]) / 2,
+		max:    d[n-1],
+		size:   msg.size,
+	}
+	lines := []string{
+		_pickerTitle.Render("benchmark of " + s.filter),
+		fmt.Sprintf("engine  %s", msg.engine),
+		fmt.Sprintf("runs    %d", n),
+		fmt.Sprintf("min     %s", formatDuration(s.min)),
+		fmt.Sprintf("median  %s", formatDuration(s.median)),
+		fmt.Sprintf("max     %s", formatDuration(s.max)),
+		fmt.Sprintf("output  %s", input.FormatBytes(s.size)),
+		"",
+	}
+	if prev := m.lastBench; prev == nil {
+		lines = append(lines, "no previous benchmark to compare with")
+	} else {
+		lines = append(lines,
+			_pickerTitle.Render("previous benchmark of "+prev.filter),
+			fmt.Sprintf("median  %s, %s", formatDuration(prev.median), compareDurations(s.median, prev.median)),
+			fmt.Sprintf("output  %s, %s", input.FormatBytes(prev.size), compareSizes(s.size, prev.size)),
+		)
+	}
+	m.lastBench = &s
+	m.setStatus(nil, "benchmark median %s", formatDuration(s.median))
+	m.openOverlay(newTextOverlay(lines, nil, m.keys.viewport))
+}
+
+// compareDurations tells how much faster or slower d is than prev.
+func compareDurations(d, prev time.Duration) string {
+	switch {
+	case d == prev || d <= 0 || prev <= 0:
+		return "as fast now"
+	case d < prev:
+		return fmt.Sprintf("%.2f× faster now", float64(prev)/float64(d))
+	default:
+		return fmt.Sprintf("%.2f× slower now", float64(d)/float64(prev))
+	}
+}
+
+// compareSizes tells how much larger or smaller an output of n bytes is
+// than one of prev bytes.
+func compareSizes(n, prev int) string {
+	switch {
+	case n == prev:
+		return "same size now"
+	case n < prev:
+		return fmt.Sprintf("%s smaller now", input.FormatBytes(prev-n))
+	default:
+		return fmt.Sprintf("%s larger now", input.FormatBytes(n-prev))
+	}
+}
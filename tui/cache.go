@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/maolonglong/ijq/engine"
+)
+
+// Limits of the result cache: the number of results kept, and their total
+// size.
+const (
+	_cacheEntries = 64
+	_cacheBytes   = 64 << 20
+)
+
+// _impure matches filters whose result can change between evaluations of
+// the same input, which are not cached.
+var _impure = regexp.MustCompile(`\b(now|input_filename)\b`)
+
+// resultCache remembers the latest evaluations by filter and options, so
+// that going back to one does not run jq again. It is safe for concurrent
+// use.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the entries, most recently used first.
+	lru  list.List
+	size int
+}
+
+type cacheEntry struct {
+	key     string
+	content string
+	msg     evalMsg
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*list.Element)}
+}
+
+func cacheKey(filter string, opts engine.Options) string {
+	return strings.Join(append(opts.Flags(), filter), "\x00")
+}
+
+func (e *cacheEntry) size() int {
+	return len(e.msg.Output) + len(e.msg.Errors)
+}
+
+// get returns the result of filter with opts for content, if it is cached.
+func (c *resultCache) get(content, filter string, opts engine.Options) (evalMsg, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[cacheKey(filter, opts)]
+	// Comparing is cheap as long as the input is the same string.
+	if !ok || el.Value.(*cacheEntry).content != content {
+		return evalMsg{}, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry).msg, true
+}
+
+// put caches msg as the result of filter with opts for content, evicting
+// the least recently used results beyond the limits.
+func (c *resultCache) put(content, filter string, opts engine.Options, msg evalMsg) {
+	if _impure.MatchString(filter) || msg.Dropped > 0 {
+		return
+	}
+	e := &cacheEntry{key: cacheKey(filter, opts), content: content, msg: msg}
+	if e.size() > _cacheBytes/4 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.size += e.size()
+	for c.lru.Len() > _cacheEntries || c.size > _cacheBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *resultCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.size -= e.size()
+}
+
+// clear drops every cached result, as when the input is reloaded.
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+	c.size = 0
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maolonglong/ijq/engine"
+)
+
+// evalOutput returns an evaluation that printed output.
+func evalOutput(output string) evalMsg {
+	return evalMsg{Result: engine.Result{Output: output}}
+}
+
+func TestResultCacheKeys(t *testing.T) {
+	c := newResultCache()
+	c.put("{}", ".a", engine.Options{}, evalOutput("1\n"))
+	tests := []struct {
+		name    string
+		content string
+		filter  string
+		opts    engine.Options
+		hit     bool
+	}{
+		{"same", "{}", ".a", engine.Options{}, true},
+		{"other input", `{"a":1}`, ".a", engine.Options{}, false},
+		{"other filter", "{}", ".b", engine.Options{}, false},
+		{"other options", "{}", ".a", engine.Options{Raw: true}, false},
+		{"other variables", "{}", ".a", engine.Options{Args: []string{"--arg", "x", "1"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := c.get(tt.content, tt.filter, tt.opts)
+			if ok != tt.hit {
+				t.Fatalf("get(%q, %q) hit = %v, want %v", tt.content, tt.filter, ok, tt.hit)
+			}
+			if ok && msg.Output != "1\n" {
+				t.Errorf("get(%q, %q) = %q, want %q", tt.content, tt.filter, msg.Output, "1\n")
+			}
+		})
+	}
+}
+
+func TestResultCacheSkips(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		msg    evalMsg
+	}{
+		{"now", "now | todate", evalOutput("x\n")},
+		{"input_filename", "input_filename", evalOutput("null\n")},
+		{"truncated", ".", evalMsg{Result: engine.Result{Output: "[", Dropped: 1}}},
+		{"too large", ".", evalOutput(strings.Repeat("x", _cacheBytes/4+1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newResultCache()
+			c.put("{}", tt.filter, engine.Options{}, tt.msg)
+			if _, ok := c.get("{}", tt.filter, engine.Options{}); ok {
+				t.Errorf("put(%q) was cached", tt.filter)
+			}
+		})
+	}
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	filter := func(i int) string { return fmt.Sprint(".[", i, "]") }
+	t.Run("entries", func(t *testing.T) {
+		c := newResultCache()
+		for i := range _cacheEntries {
+			c.put("[]", filter(i), engine.Options{}, evalOutput("x"))
+		}
+		// Using the oldest makes the second oldest the least recently used.
+		if _, ok := c.get("[]", filter(0), engine.Options{}); !ok {
+			t.Fatal("oldest result missing before the cache is full")
+		}
+		c.put("[]", filter(_cacheEntries), engine.Options{}, evalOutput("x"))
+		if _, ok := c.get("[]", filter(0), engine.Options{}); !ok {
+			t.Error("recently used result was evicted")
+		}
+		if _, ok := c.get("[]", filter(1), engine.Options{}); ok {
+			t.Error("least recently used result was kept")
+		}
+		if n := c.lru.Len(); n != _cacheEntries {
+			t.Errorf("cache holds %d results, want %d", n, _cacheEntries)
+		}
+	})
+	t.Run("size", func(t *testing.T) {
+		c := newResultCache()
+		big := evalOutput(strings.Repeat("x", _cacheBytes/4))
+		for i := range 5 {
+			c.put("[]", filter(i), engine.Options{}, big)
+		}
+		if _, ok := c.get("[]", filter(0), engine.Options{}); ok {
+			t.Error("oldest result kept beyond the size limit")
+		}
+		for i := 1; i < 5; i++ {
+			if _, ok := c.get("[]", filter(i), engine.Options{}); !ok {
+				t.Errorf("result %d evicted within the size limit", i)
+			}
+		}
+		if c.size != _cacheBytes {
+			t.Errorf("size = %d, want %d", c.size, _cacheBytes)
+		}
+	})
+	t.Run("replace", func(t *testing.T) {
+		c := newResultCache()
+		c.put("[]", ".", engine.Options{}, evalOutput("old"))
+		c.put("[]", ".", engine.Options{}, evalMsg{Result: engine.Result{Output: "new", Errors: "e"}})
+		msg, ok := c.get("[]", ".", engine.Options{})
+		if !ok || msg.Output != "new" {
+			t.Errorf("get after replacing = %q, %v; want %q", msg.Output, ok, "new")
+		}
+		if c.lru.Len() != 1 || c.size != len("new")+len("e") {
+			t.Errorf("cache holds %d results of %d bytes, want 1 of %d", c.lru.Len(), c.size, len("new")+len("e"))
+		}
+	})
+	t.Run("clear", func(t *testing.T) {
+		c := newResultCache()
+		c.put("[]", ".", engine.Options{}, evalOutput("x"))
+		c.clear()
+		if _, ok := c.get("[]", ".", engine.Options{}); ok || c.size != 0 || c.lru.Len() != 0 {
+			t.Errorf("cache not empty after clear: %d results of %d bytes", c.lru.Len(), c.size)
+		}
+	})
+}
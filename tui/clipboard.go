@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// _maxOSC52 is the largest text copied with OSC 52. Terminals cap the
+// length of the sequence, and xterm, for one, ignores longer ones.
+const _maxOSC52 = 74994
+
+// writeClipboard copies s to the system clipboard. It sends an OSC 52
+// escape sequence, which the terminal handles even over ssh, and also pipes
+// s to the platform's clipboard tool if there is one, since not every
+// terminal supports OSC 52.
+func writeClipboard(term io.Writer, s string) error {
+	copied := false
+	if term != nil && len(s) <= _maxOSC52 {
+		if _, err := io.WriteString(term, ansi.SetSystemClipboard(s)); err == nil {
+			copied = true
+		}
+	}
+	if args := clipboardCommand(); args != nil {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(s)
+		if err := cmd.Run(); err != nil && !copied {
+			return err
+		} else if err == nil {
+			copied = true
+		}
+	}
+	if !copied {
+		return errors.New("no clipboard available")
+	}
+	return nil
+}
+
+// clipboardCommand returns the command that copies its input to the
+// clipboard on this system, or nil if none is installed.
+func clipboardCommand() []string {
+	switch {
+	case runtime.GOOS == "darwin":
+		return installed([]string{"pbcopy"})
+	case runtime.GOOS == "windows":
+		return installed([]string{"clip"})
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		return installed([]string{"wl-copy"})
+	case os.Getenv("DISPLAY") != "":
+		return installed([]string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	// Windows clip.exe is on the PATH under WSL.
+	return installed([]string{"clip.exe"})
+}
+
+// pasteCommand returns the command that prints the contents of the
+// clipboard on this system, or nil if none is installed.
+func pasteCommand() []string {
+	switch {
+	case runtime.GOOS == "darwin":
+		return installed([]string{"pbpaste"})
+	case runtime.GOOS == "windows":
+		return installed([]string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"})
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		return installed([]string{"wl-paste", "--no-newline"})
+	case os.Getenv("DISPLAY") != "":
+		return installed([]string{"xclip", "-selection", "clipboard", "-out"}, []string{"xsel", "--clipboard", "--output"})
+	}
+	return installed([]string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"})
+}
+
+// installed returns the first of the commands that is installed, or nil.
+func installed(commands ...[]string) []string {
+	for _, c := range commands {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// ReadClipboard returns the contents of the system clipboard, for --paste.
+func ReadClipboard() (string, error) {
+	args := pasteCommand()
+	if args == nil {
+		return "", errors.New("no clipboard available to paste from")
+	}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// copy copies s to the clipboard and reports it as what in the status bar.
+func (m *model) copy(what, s string) {
+	m.setStatus(writeClipboard(m.term, s), "copied %s", what)
+}
+
+// copyPath copies the jq path of the value under the cursor in the tree
+// view, or of the value on the top line of the viewport.
+func (m *model) copyPath() {
+	path, err := m.cursorPath()
+	if err != nil {
+		m.setStatus(err, "")
+		return
+	}
+	m.copy("path "+path, path)
+}
+
+func (m model) cursorPath() (string, error) {
+	if m.tree != nil {
+		if n := m.tree.node(); n != nil {
+			return jqPath(n.path[1:]), nil
+		}
+		return "", errors.New("nothing to copy")
+	}
+	if m.gron && !m.showOriginal && !m.diff {
+		if line := m.lineAt(m.viewport.YOffset); line < len(m.gronPaths) {
+			return jqPath(m.gronPaths[line][1:]), nil
+		}
+		return "", errors.New("nothing to copy")
+	}
+	// The pretty-printed result has a line per line of the fully expanded
+	// tree, and the compact one a line per document.
+	t, err := newTree(m.shownText(), nil)
+	if err != nil {
+		return "", err
+	}
+	line := m.lineAt(m.viewport.YOffset)
+	switch {
+	case m.evalOptions.Compact && line < len(t.roots):
+		return ".", nil
+	case !m.evalOptions.Compact && line < len(t.lines):
+		return jqPath(t.lines[line].node.path[1:]), nil
+	}
+	return "", errors.New("nothing to copy")
+}
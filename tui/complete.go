@@ -0,0 +1,230 @@
+package tui
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// _maxCompletions is the number of completions shown at once.
+const _maxCompletions = 8
+
+var (
+	_completion         = lipgloss.NewStyle().Background(lipgloss.Color("0")).Foreground(lipgloss.Color("7"))
+	_selectedCompletion = lipgloss.NewStyle().Reverse(true)
+)
+
+// _builtins lists the signatures of jq's builtin functions, keywords and
+// formats offered as completions.
+var _builtins = []string{
+	"@base32", "@base32d", "@base64", "@base64d", "@csv", "@html", "@json", "@sh", "@text", "@tsv", "@uri",
+	"$ENV", "$__loc__",
+	"abs", "add", "add(f)", "all", "all(f)", "all(gen; f)", "and", "any", "any(f)", "any(gen; f)", "as",
+	"ascii_downcase", "ascii_upcase", "builtins", "capture(re)", "capture(re; flags)", "catch", "ceil",
+	"combinations", "combinations(n)", "contains(x)", "debug", "debug(msg)", "def", "del(path)",
+	"delpaths(paths)", "elif", "else", "empty", "end", "endswith(s)", "env", "error", "error(msg)",
+	"explode", "first", "first(f)", "flatten", "flatten(depth)", "floor", "foreach", "from_entries",
+	"fromdate", "fromjson", "fromstream(f)", "getpath(path)", "gmtime", "group_by(f)",
+	"gsub(re; str)", "gsub(re; str; flags)", "halt", "halt_error", "halt_error(code)", "has(key)", "if",
+	"implode", "in(obj)", "index(s)", "indices(s)", "infinite", "input", "input_filename",
+	"input_line_number", "inputs", "inside(x)", "isinfinite", "isnan", "isnormal", "isvalid(f)",
+	"join(sep)", "keys", "keys_unsorted", "label", "last", "last(f)", "leaf_paths", "length", "limit(n; f)",
+	"localtime", "ltrimstr(s)", "map(f)", "map_values(f)", "match(re)", "match(re; flags)", "max",
+	"max_by(f)", "min", "min_by(f)", "mktime", "nan", "not", "now", "nth(n)", "nth(n; f)", "or", "path(f)",
+	"paths", "paths(f)", "pick(path)", "pow(x; y)", "range(n)", "range(from; upto)",
+	"range(from; upto; by)", "recurse", "recurse(f)", "recurse(f; cond)", "reduce", "repeat(f)",
+	"reverse", "rindex(s)", "round", "rtrimstr(s)", "scan(re)", "scan(re; flags)", "select(f)",
+	"setpath(path; value)", "sort", "sort_by(f)", "split(s)", "split(re; flags)", "splits(re)",
+	"sqrt", "startswith(s)", "stderr", "strftime(format)", "strptime(format)", "sub(re; str)",
+	"sub(re; str; flags)", "test(re)", "test(re; flags)", "then", "to_entries", "toarray", "todate",
+	"tojson", "tonumber", "tostream", "tostring", "transpose", "trim", "ltrim", "rtrim", "try",
+	"type", "unique", "unique_by(f)", "until(cond; next)", "utf8bytelength", "values",
+	"walk(f)", "while(cond; update)", "with_entries(f)",
+}
+
+// completion is a word offered in the completion menu: text is inserted
+// and label is shown.
+type completion struct {
+	text  string
+	label string
+}
+
+type completionKeyMap struct {
+	accept  key.Binding
+	prev    key.Binding
+	next    key.Binding
+	dismiss key.Binding
+}
+
+func (k completionKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.accept, k.next, k.prev, k.dismiss}
+}
+
+func (k completionKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// completer suggests completions for the word before the cursor in the
+// one-line filter input.
+type completer struct {
+	keys      completionKeyMap
+	items     []completion
+	selected  int
+	start     int
+	word      string
+	dismissed string
+	// library holds the functions of the user's ~/.jq, which are offered
+	// before the builtins.
+	library []string
+}
+
+func newCompleter(library []string) completer {
+	return completer{
+		library: library,
+		keys: completionKeyMap{
+			accept: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp("tab", "complete"),
+			),
+			prev: key.NewBinding(
+				key.WithKeys("up", "ctrl+p"),
+				key.WithHelp("↑", "previous completion"),
+			),
+			next: key.NewBinding(
+				key.WithKeys("down", "ctrl+n"),
+				key.WithHelp("↓", "next completion"),
+			),
+			dismiss: key.NewBinding(
+				key.WithKeys("esc"),
+				key.WithHelp("esc", "close completions"),
+			),
+		},
+	}
+}
+
+func (c completer) visible() bool {
+	return len(c.items) > 0
+}
+
+// isWordChar reports whether r can be part of a jq identifier, variable or
+// format name.
+func isWordChar(r rune) bool {
+	return r == '_' || r == '$' || r == '@' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// funcCompletions returns the functions of library and the builtins
+// starting with word.
+func funcCompletions(word string, library []string) []completion {
+	var items []completion
+	for _, sig := range slices.Concat(library, _builtins) {
+		name, _, _ := strings.Cut(sig, "(")
+		if strings.HasPrefix(name, word) && sig != word {
+			items = append(items, completion{text: name, label: sig})
+		}
+	}
+	return items
+}
+
+// updateCompletions finds the completions for the word before the cursor.
+func (m *model) updateCompletions() {
+	c := &m.completer
+	c.items = c.items[:0]
+	c.selected = 0
+	if m.multiline || m.focusViewport {
+		return
+	}
+	rs := []rune(m.textinput.Value())
+	pos := min(m.textinput.Position(), len(rs))
+	start := pos
+	for start > 0 && isWordChar(rs[start-1]) {
+		start--
+	}
+	word := string(rs[start:pos])
+	at := strconv.Itoa(start) + ":" + word
+	if at != c.dismissed {
+		c.dismissed = ""
+	}
+	if at == c.dismissed {
+		return
+	}
+	c.start, c.word = start, word
+	switch {
+	case start > 0 && rs[start-1] == '.':
+		if m.keyIndex == nil {
+			m.keyIndex = newKeyIndex(m.content)
+		}
+		chain := _pathChain.FindString(string(rs[:start-1]))
+		c.items = m.keyIndex.complete(chain, word)
+	case word != "":
+		c.items = funcCompletions(word, c.library)
+	}
+}
+
+// updateCompleter handles a key press while completions are shown,
+// reporting whether it was used.
+func (m *model) updateCompleter(msg tea.KeyMsg) (bool, tea.Cmd) {
+	c := &m.completer
+	switch {
+	case key.Matches(msg, c.keys.accept):
+		item := c.items[c.selected]
+		rs := []rune(m.textinput.Value())
+		pos := c.start + len([]rune(c.word))
+		v := string(rs[:c.start]) + item.text + string(rs[pos:])
+		m.textinput.SetValue(v)
+		m.textinput.SetCursor(c.start + len([]rune(item.text)))
+		c.items = c.items[:0]
+		m.updateSuggestion()
+		m.validateFilter()
+		if m.live {
+			return true, m.scheduleEval()
+		}
+		return true, nil
+	case key.Matches(msg, c.keys.prev):
+		c.selected = (c.selected - 1 + len(c.items)) % len(c.items)
+	case key.Matches(msg, c.keys.next):
+		c.selected = (c.selected + 1) % len(c.items)
+	case key.Matches(msg, c.keys.dismiss):
+		c.dismissed = strconv.Itoa(c.start) + ":" + c.word
+		c.items = c.items[:0]
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// completionsView draws the completion menu over the top of view, below
+// the word being completed.
+func (m model) completionsView(view string) string {
+	c := m.completer
+	first := max(c.selected-_maxCompletions+1, 0)
+	items := c.items[first:min(first+_maxCompletions, len(c.items))]
+	width := 0
+	for _, it := range items {
+		width = max(width, ansi.StringWidth(it.label))
+	}
+	width += 2
+	col := min(ansi.StringWidth(m.textinput.Prompt)+max(c.start-m.filterScroll(), 0), max(m.width-width, 0))
+
+	lines := strings.Split(view, "\n")
+	for i, it := range items {
+		if i >= len(lines) {
+			break
+		}
+		label := " " + it.label + strings.Repeat(" ", width-1-ansi.StringWidth(it.label))
+		if first+i == c.selected {
+			label = _selectedCompletion.Render(label)
+		} else {
+			label = _completion.Render(label)
+		}
+		left := ansi.Truncate(lines[i], col, "")
+		left += strings.Repeat(" ", col-ansi.StringWidth(left))
+		lines[i] = left + "\x1b[0m" + label + ansiutil.CutLeft(lines[i], col+width)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+)
+
+const (
+	_colorAdded   = "32"
+	_colorRemoved = "31"
+	_colorHunk    = "36"
+
+	// _diffContext is the number of unchanged lines around each change.
+	_diffContext = 3
+
+	// _maxDiffCells caps the memory the diff may use, in ints. Beyond it
+	// the old text is shown as replaced by the new one.
+	_maxDiffCells = 1 << 24
+)
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines computes a shortest edit script from a to b with Myers'
+// algorithm, after trimming their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	size := n + m
+	if size == 0 {
+		return nil
+	}
+	off := size
+	v := make([]int, 2*size+1)
+	var trace [][]int
+	for d := 0; d <= size; d++ {
+		if (d+1)*len(v) > _maxDiffCells {
+			return replaceLines(a, b)
+		}
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, off)
+			}
+		}
+	}
+	return replaceLines(a, b)
+}
+
+// backtrack walks the saved frontiers back from the end of both texts to
+// recover the edits.
+func backtrack(trace [][]int, a, b []string, off int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for ; x > 0 && y > 0; x, y = x-1, y-1 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+func replaceLines(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a {
+		ops = append(ops, diffOp{'-', l})
+	}
+	for _, l := range b {
+		ops = append(ops, diffOp{'+', l})
+	}
+	return ops
+}
+
+// unifiedDiff formats the changes from old to new as a colored unified
+// diff, or returns the empty string if there are none.
+func unifiedDiff(old, new string) string {
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(strings.TrimSuffix(ansi.Strip(s), "\n"), "\n")
+	}
+	ops := diffLines(split(old), split(new))
+
+	// Every change gets _diffContext lines of context; hunks whose context
+	// overlaps are merged.
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(i-_diffContext, 0), min(i+_diffContext+1, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+
+	var sb strings.Builder
+	ai, bi, pos := 0, 0, 0
+	for _, h := range hunks {
+		for ; pos < h.start; pos++ {
+			ai, bi = ai+1, bi+1
+		}
+		var na, nb int
+		for _, op := range ops[h.start:h.end] {
+			if op.kind != '+' {
+				na++
+			}
+			if op.kind != '-' {
+				nb++
+			}
+		}
+		engine.Colorize(&sb, _colorHunk, fmt.Sprintf("@@ -%s +%s @@", hunkRange(ai, na), hunkRange(bi, nb)))
+		sb.WriteByte('\n')
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case '-':
+				engine.Colorize(&sb, _colorRemoved, "-"+op.line)
+				ai++
+			case '+':
+				engine.Colorize(&sb, _colorAdded, "+"+op.line)
+				bi++
+			default:
+				sb.WriteString(" " + op.line)
+				ai, bi = ai+1, bi+1
+			}
+			sb.WriteByte('\n')
+		}
+		pos = h.end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of a hunk starting after line start.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// toggleDiff switches between the result and its diff against the previous
+// one.
+func (m *model) toggleDiff() {
+	m.diff = !m.diff
+	m.updateDiff()
+	m.setStatus(nil, "diff view %s", onOff(m.diff))
+	m.resetView()
+}
+
+// updateDiff recomputes the diff shown in diff view.
+func (m *model) updateDiff() {
+	if !m.diff {
+		m.diffText = ""
+		return
+	}
+	m.diffText = unifiedDiff(m.prevResult, m.result)
+	if m.diffText == "" {
+		m.diffText = _statusInfo.Render("no changes from the previous result") + "\n"
+	}
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// switchDocument moves delta documents to the right, wrapping around, and
+// evaluates the filter on it. The filters of pipeline stages are kept as a
+// part of the filter, since their results were those of the old document.
+func (m *model) switchDocument(delta int) tea.Cmd {
+	if len(m.documents) < 2 {
+		return nil
+	}
+	m.activeDoc = (m.activeDoc + delta + len(m.documents)) % len(m.documents)
+	doc := m.documents[m.activeDoc]
+	filter := m.filterValue()
+	if len(m.stages) > 0 {
+		filters := make([]string, 0, len(m.stages)+1)
+		for _, s := range m.stages {
+			filters = append(filters, s.filter)
+		}
+		filter = strings.Join(append(filters, filter), " | ")
+		m.stages = nil
+	}
+	records := input.CountRecords(doc.Content)
+	m.replaceRoot(doc.Content, records)
+	cmd := m.setInput(doc.Content, filter, records)
+	m.setStatus(nil, "document %d of %d: %s", m.activeDoc+1, len(m.documents), doc.Name)
+	return cmd
+}
+
+// replaceRoot makes content, which has the given number of JSON values,
+// the document ijq resets to, and the input of the other tabs that were on
+// the old one.
+func (m *model) replaceRoot(content string, records int) {
+	old := m.rootContent
+	m.rootContent, m.rootRecords = content, records
+	for i := range m.tabs {
+		if t := &m.tabs[i]; i != m.activeTab && len(t.stages) == 0 && t.content == old {
+			t.content, t.records = content, records
+			t.evaluated = ""
+		}
+	}
+}
+
+// documentsView renders the names of the documents, or nothing if there is
+// a single one.
+func (m model) documentsView() string {
+	if len(m.documents) < 2 {
+		return ""
+	}
+	var names []string
+	for i, d := range m.documents {
+		name := d.Name
+		if !input.IsURL(name) {
+			name = filepath.Base(name)
+		}
+		if i == m.activeDoc {
+			names = append(names, _activeTab.Render(truncate(name, 30)))
+		} else {
+			names = append(names, _tab.Render(truncate(name, 30)))
+		}
+	}
+	return ansi.Truncate(strings.Join(names, ""), m.width, "…")
+}
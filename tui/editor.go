@@ -0,0 +1,305 @@
+package tui
+
+import (
+	"cmp"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// _maxEditorHeight is the most lines the multiline editor grows to before
+// it scrolls.
+const _maxEditorHeight = 10
+
+// editingKeyMap holds the readline keys that move the cursor and delete in
+// the filter input and the multiline editor.
+type editingKeyMap struct {
+	lineStart          key.Binding
+	lineEnd            key.Binding
+	wordBackward       key.Binding
+	wordForward        key.Binding
+	deleteWordBackward key.Binding
+	deleteWordForward  key.Binding
+	deleteBeforeCursor key.Binding
+	deleteAfterCursor  key.Binding
+}
+
+func defaultEditingKeyMap() editingKeyMap {
+	return editingKeyMap{
+		lineStart: key.NewBinding(
+			key.WithKeys("home", "ctrl+a"),
+			key.WithHelp("ctrl+a", "start of line"),
+		),
+		// ctrl+e opens the filter in $EDITOR instead.
+		lineEnd: key.NewBinding(
+			key.WithKeys("end"),
+			key.WithHelp("end", "end of line"),
+		),
+		wordBackward: key.NewBinding(
+			key.WithKeys("alt+b"),
+			key.WithHelp("alt+b", "word back"),
+		),
+		wordForward: key.NewBinding(
+			key.WithKeys("alt+f"),
+			key.WithHelp("alt+f", "word forward"),
+		),
+		deleteWordBackward: key.NewBinding(
+			key.WithKeys("alt+backspace", "ctrl+w"),
+			key.WithHelp("ctrl+w", "delete word back"),
+		),
+		deleteWordForward: key.NewBinding(
+			key.WithKeys("alt+delete", "alt+d"),
+			key.WithHelp("alt+d", "delete word forward"),
+		),
+		deleteBeforeCursor: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "delete to start"),
+		),
+		// Likewise, ctrl+k opens the command palette, so deleting to the
+		// end of the line takes a key only if one is given in the config
+		// file.
+		deleteAfterCursor: key.NewBinding(
+			key.WithHelp("", "delete to end"),
+		),
+	}
+}
+
+// apply binds the keys in the filter input and the multiline editor.
+func (k editingKeyMap) apply(ti *textinput.KeyMap, ta *textarea.KeyMap) {
+	ti.LineStart, ta.LineStart = k.lineStart, k.lineStart
+	ti.LineEnd, ta.LineEnd = k.lineEnd, k.lineEnd
+	ti.WordBackward, ta.WordBackward = k.wordBackward, k.wordBackward
+	ti.WordForward, ta.WordForward = k.wordForward, k.wordForward
+	ti.DeleteWordBackward, ta.DeleteWordBackward = k.deleteWordBackward, k.deleteWordBackward
+	ti.DeleteWordForward, ta.DeleteWordForward = k.deleteWordForward, k.deleteWordForward
+	ti.DeleteBeforeCursor, ta.DeleteBeforeCursor = k.deleteBeforeCursor, k.deleteBeforeCursor
+	ti.DeleteAfterCursor, ta.DeleteAfterCursor = k.deleteAfterCursor, k.deleteAfterCursor
+}
+
+func newEditor() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "jq program"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetHeight(1)
+	return ta
+}
+
+// filterValue returns the filter as typed in the active input.
+func (m model) filterValue() string {
+	if m.multiline {
+		return m.editor.Value()
+	}
+	return m.textinput.Value()
+}
+
+// setFilterValue replaces the filter in the active input, leaving the
+// cursor at its end.
+func (m *model) setFilterValue(s string) {
+	if m.multiline {
+		m.editor.SetValue(s)
+		m.fitEditor()
+		m.validateFilter()
+		return
+	}
+	m.textinput.SetValue(s)
+	m.textinput.CursorEnd()
+	m.completer.items = nil
+	m.updateSuggestion()
+	m.validateFilter()
+}
+
+// toggleMultiline switches the filter between the one-line input and the
+// multiline editor, carrying the filter over.
+func (m *model) toggleMultiline() tea.Cmd {
+	v := m.filterValue()
+	m.multiline = !m.multiline
+	m.keys.multiline = m.multiline
+	m.setFilterValue(v)
+	m.setStatus(nil, "multiline editor %s", onOff(m.multiline))
+	if m.focusViewport {
+		return nil
+	}
+	return m.focusInput()
+}
+
+func (m *model) focusInput() tea.Cmd {
+	if m.multiline {
+		m.textinput.Blur()
+		return m.editor.Focus()
+	}
+	m.editor.Blur()
+	return m.textinput.Focus()
+}
+
+func (m *model) blurInput() {
+	m.textinput.Blur()
+	m.editor.Blur()
+}
+
+// updateInput passes a key press to the active input, scheduling an
+// evaluation if live eval is on and the filter changed.
+func (m *model) updateInput(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	prev := m.filterValue()
+	if m.multiline {
+		// Make room for a new line first, or the editor scrolls to it.
+		h := m.editor.Height()
+		m.editor.SetHeight(min(m.editor.LineCount()+1, _maxEditorHeight))
+		m.editor, cmd = m.editor.Update(msg)
+		m.fitEditor()
+		if m.editor.Height() != h {
+			m.resize()
+		}
+	} else if v := []rune(m.textinput.Value()); key.Matches(msg, m.keys.editing.deleteWordForward) && m.textinput.Position() == len(v)-1 {
+		// textinput reads past the end deleting a word of one character.
+		m.textinput.SetValue(string(v[:len(v)-1]))
+	} else {
+		m.textinput, cmd = m.textinput.Update(msg)
+	}
+	if m.live && m.filterValue() != prev {
+		cmd = tea.Batch(cmd, m.scheduleEval())
+	}
+	m.updateCompletions()
+	m.updateSuggestion()
+	m.validateFilter()
+	return cmd
+}
+
+// _newlines turns the line endings of pasted text into newlines: many
+// terminals paste a newline as a carriage return.
+var _newlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// paste inserts text pasted into the terminal into the filter at once, so
+// that it is evaluated once rather than for every character. A paste of
+// several lines opens the multiline editor, where the one-line input
+// would turn its newlines into spaces and break any comments.
+func (m *model) paste(text string) tea.Cmd {
+	text = strings.TrimSuffix(_newlines.Replace(text), "\n")
+	var cmd tea.Cmd
+	if m.focusViewport {
+		cmd = m.focusFilter()
+	}
+	if strings.Contains(text, "\n") && !m.multiline {
+		cmd = tea.Batch(cmd, m.toggleMultiline())
+	}
+	prev := m.filterValue()
+	if m.multiline {
+		m.editor.InsertString(text)
+		m.fitEditor()
+		m.resize()
+	} else {
+		v := []rune(m.textinput.Value())
+		pos := min(m.textinput.Position(), len(v))
+		m.textinput.SetValue(string(v[:pos]) + text + string(v[pos:]))
+		m.textinput.SetCursor(pos + len([]rune(text)))
+	}
+	if m.live && m.filterValue() != prev {
+		cmd = tea.Batch(cmd, m.scheduleEval())
+	}
+	// A paste is not typing a word, so it opens no completions.
+	m.completer.items = nil
+	m.updateSuggestion()
+	m.validateFilter()
+	return cmd
+}
+
+// updateSuggestion shows the rest of the newest history entry starting
+// with the filter after the cursor, like fish's autosuggestions. It is
+// only offered with the cursor at the end of the line and no completion
+// menu open.
+func (m *model) updateSuggestion() {
+	var suggestions []string
+	v := m.textinput.Value()
+	if v != "" && !m.completer.visible() && m.textinput.Position() == len([]rune(v)) {
+		if s := m.history.suggest(v); s != "" {
+			suggestions = []string{s}
+		}
+	}
+	m.textinput.SetSuggestions(suggestions)
+}
+
+// fitEditor grows or shrinks the editor to its number of lines.
+func (m *model) fitEditor() {
+	m.editor.SetHeight(min(m.editor.LineCount(), _maxEditorHeight))
+}
+
+// inputView renders the active input, with the spinner in place of the
+// prompt while the filter is evaluated.
+func (m model) inputView() string {
+	if m.multiline {
+		ta := m.editor
+		if m.syntaxErr != nil {
+			ta.FocusedStyle.Prompt = ta.FocusedStyle.Prompt.Foreground(lipgloss.Color("1"))
+		}
+		if m.evaluating() {
+			spin := m.spinner.View()
+			ta.SetPromptFunc(2, func(line int) string {
+				if line == 0 {
+					return spin
+				}
+				return strings.Repeat(" ", 2)
+			})
+		}
+		return ta.View()
+	}
+	prompt := m.textinput.Prompt
+	if m.evaluating() {
+		prompt = m.spinner.View()
+	}
+	return m.filterView(prompt)
+}
+
+// editorMsg reports that the external editor exited.
+type editorMsg struct {
+	path string
+	err  error
+}
+
+// openEditor suspends the interface and opens the filter in $VISUAL or
+// $EDITOR as a .jq file.
+func (m *model) openEditor() tea.Cmd {
+	f, err := os.CreateTemp("", "ijq-*.jq")
+	if err == nil {
+		_, err = f.WriteString(m.filterValue() + "\n")
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		m.setStatus(err, "")
+		return nil
+	}
+	editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	c := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorMsg{path: f.Name(), err: err}
+	})
+}
+
+// editorDone loads the filter saved in the editor and evaluates it. A
+// filter of several lines opens the multiline editor.
+func (m *model) editorDone(msg editorMsg) tea.Cmd {
+	defer os.Remove(msg.path)
+	b, err := os.ReadFile(msg.path)
+	if err == nil {
+		err = msg.err
+	}
+	if err != nil {
+		m.setStatus(err, "")
+		return m.restoreMouse()
+	}
+	filter := strings.TrimRight(string(b), "\n")
+	if strings.Contains(filter, "\n") && !m.multiline {
+		m.toggleMultiline()
+	}
+	m.setFilterValue(filter)
+	m.setStatus(nil, "")
+	return tea.Batch(m.restoreMouse(), m.startEval())
+}
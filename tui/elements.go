@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// elementView shows a result that is a single array one element at a time,
+// to step through rather than scroll.
+type elementView struct {
+	on bool
+	// elements holds the colored text of each element of the result, nil
+	// if it is not an array.
+	elements []string
+	current  int
+}
+
+// splitArray splits the JSON text of a single array, as jq prints it, into
+// the texts of its elements, unindented by one level. It reports false if
+// s is anything else, such as several values or a raw string.
+func splitArray(s string) ([]string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") {
+		return nil, false
+	}
+	elements := []string{}
+	depth, start := 0, 1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			i = engine.StringEnd(s, i) - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				if i != len(s)-1 {
+					return nil, false
+				}
+				if e := s[start:i]; strings.TrimSpace(e) != "" {
+					elements = append(elements, unindent(e))
+				}
+				return elements, true
+			}
+		case ',':
+			if depth == 1 {
+				elements = append(elements, unindent(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return nil, false
+}
+
+// unindent trims the space around an element and removes the indentation
+// of its first line from the others.
+func unindent(e string) string {
+	e = strings.TrimRight(strings.TrimLeft(e, "\n"), " \t\n")
+	indent := e[:len(e)-len(strings.TrimLeft(e, " \t"))]
+	lines := strings.Split(e, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// updateElements splits the result into its elements while the element
+// view is on, keeping the element shown if there are still as many.
+func (m *model) updateElements() {
+	v := &m.elements
+	v.elements = nil
+	if !v.on {
+		return
+	}
+	elements, ok := splitArray(ansi.Strip(m.redacted(m.result)))
+	if !ok {
+		return
+	}
+	v.elements = make([]string, len(elements))
+	for i, e := range elements {
+		v.elements[i] = engine.ColorJSON(e)
+	}
+	v.current = min(v.current, max(len(v.elements)-1, 0))
+}
+
+func (m *model) toggleElements() {
+	m.elements.on = !m.elements.on
+	m.elements.current = 0
+	m.updateElements()
+	m.setStatus(nil, "element view %s", onOff(m.elements.on))
+	m.resetView()
+}
+
+// stepElement shows the next element of the array, or with delta -1 the
+// previous one, stopping at either end.
+func (m *model) stepElement(delta int) {
+	v := &m.elements
+	if len(v.elements) == 0 {
+		return
+	}
+	v.current = min(max(v.current+delta, 0), len(v.elements)-1)
+	m.resetView()
+}
+
+// element returns the element shown, if any.
+func (v elementView) element() (string, bool) {
+	if !v.on || len(v.elements) == 0 {
+		return "", false
+	}
+	return v.elements[v.current], true
+}
+
+// counter tells which element is shown, for the status bar.
+func (v elementView) counter() string {
+	switch {
+	case !v.on:
+		return ""
+	case v.elements == nil:
+		return "not an array"
+	case len(v.elements) == 0:
+		return "empty array"
+	}
+	return fmt.Sprintf("element %s/%s", formatCount(v.current+1), formatCount(len(v.elements)))
+}
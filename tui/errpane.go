@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// _maxErrorLines caps the height of the error pane.
+const _maxErrorLines = 5
+
+var (
+	_errorPane = lipgloss.NewStyle().
+			Border(lipgloss.ThickBorder(), false, false, false, true).
+			BorderForeground(lipgloss.Color("1")).
+			Foreground(lipgloss.Color("1")).
+			PaddingLeft(1)
+	_errorLocation = lipgloss.NewStyle().Bold(true).Underline(true)
+
+	// _errorLocationRe matches the positions jq reports, such as
+	// "at <top-level>, line 1:" and "(at <stdin>:3)".
+	_errorLocationRe = regexp.MustCompile(`at <[^>]+>, line \d+(?:, column \d+)?|\(at <[^>]+>:\d+\)`)
+)
+
+// errorView renders jq's diagnostics in a red pane with the error location
+// highlighted, or nothing if there are none.
+func errorView(text string, width int) string {
+	text = strings.TrimRight(ansiutil.Sanitize(text), "\n")
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > _maxErrorLines {
+		n := len(lines) - _maxErrorLines + 1
+		lines = append(lines[:_maxErrorLines-1], fmt.Sprintf("… %d more lines", n))
+	}
+	for i, line := range lines {
+		lines[i] = _errorLocationRe.ReplaceAllStringFunc(line, func(s string) string {
+			return _errorLocation.Render(s)
+		})
+	}
+	return _errorPane.Width(max(width-1, 0)).MaxHeight(_maxErrorLines).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// _exitTimeout is the exit status of an evaluation stopped by --timeout,
+// as with timeout(1).
+const _exitTimeout = 124
+
+// DefaultDebounce is how long live evaluation waits after the last
+// keystroke before running jq.
+const DefaultDebounce = 200 * time.Millisecond
+
+// debounceMsg fires once the debounce interval has elapsed; it is stale if
+// another keystroke arrived in the meantime.
+type debounceMsg struct {
+	id int
+}
+
+// evalMsg carries the outcome of a finished evaluation.
+type evalMsg struct {
+	id       int
+	filter   string
+	duration time.Duration
+	// cached is set if the result comes from the result cache.
+	cached bool
+	engine.Result
+}
+
+// scheduleEval invalidates any pending or in-flight evaluation and arranges
+// for a new one to start after the debounce interval.
+func (m *model) scheduleEval() tea.Cmd {
+	m.stopEval()
+	id := m.evalID
+	return tea.Tick(m.debounce, func(time.Time) tea.Msg {
+		return debounceMsg{id: id}
+	})
+}
+
+// startEval cancels any in-flight evaluation and runs the current filter in
+// the background, spinning the spinner until it finishes. A cached result
+// is used right away instead.
+func (m *model) startEval() tea.Cmd {
+	var tick tea.Cmd
+	if !m.evaluating() {
+		tick = m.spinner.Tick
+	}
+	m.stopEval()
+	id, eng, cache, content, filter, opts := m.evalID, m.engine, m.cache, m.content, m.jqFilter(), m.runOptions()
+	if msg, ok := cache.get(content, filter, opts); ok {
+		msg.id, msg.cached = id, true
+		return func() tea.Msg { return msg }
+	}
+	ctx, cancel := m.evalContext()
+	m.cancelEval = cancel
+	opts.Output = &engine.OutputBuffer{Limit: engine.MaxOutput}
+	m.stream, m.streamShown, m.evalStart = opts.Output, 0, time.Now()
+	timeout := m.timeout
+	return tea.Batch(tick, func() tea.Msg {
+		defer cancel()
+		start := time.Now()
+		res := eng.Eval(ctx, content, filter, opts)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res = timedOut(timeout)
+		}
+		msg := evalMsg{id: id, filter: filter, duration: time.Since(start), Result: res}
+		if ctx.Err() == nil {
+			cache.put(content, filter, opts, msg)
+		}
+		return msg
+	})
+}
+
+// runOptions returns the options the filter is evaluated with.
+func (m model) runOptions() engine.Options {
+	opts := m.evalOptions
+	// Later stages read the outputs of the previous one, one by one.
+	if len(m.stages) > 0 {
+		opts.Slurp, opts.NullInput, opts.Stream = false, false, false
+	}
+	return opts
+}
+
+// evalContext returns the context of an evaluation, which ends after
+// --timeout.
+func (m model) evalContext() (context.Context, context.CancelFunc) {
+	if m.timeout > 0 {
+		return context.WithTimeout(context.Background(), m.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// timedOut is the result of an evaluation stopped by --timeout.
+func timedOut(timeout time.Duration) engine.Result {
+	return engine.Result{
+		Errors:   fmt.Sprintf("ijq: evaluation timed out after %s\n", timeout),
+		ExitCode: _exitTimeout,
+	}
+}
+
+func (m model) evaluating() bool {
+	return m.cancelEval != nil
+}
+
+// stopEval kills the in-flight jq process, if any, and makes sure that
+// results of earlier evaluations are discarded.
+func (m *model) stopEval() {
+	if m.cancelEval != nil {
+		m.cancelEval()
+		m.cancelEval = nil
+	}
+	m.endStream()
+	m.evalID++
+}
+
+// switchEngine swaps jq, or the binary given with --engine, for the
+// embedded gojq or back, and evaluates the filter again with it.
+func (m *model) switchEngine() tea.Cmd {
+	name := "gojq"
+	if _, ok := m.engine.(engine.GojqEngine); ok {
+		name = m.engineName
+		if name == "" || name == "gojq" {
+			name = "jq"
+		}
+	}
+	eng, err := engine.New(name, m.jqPath)
+	if err == nil {
+		err = eng.Check(m.evalOptions)
+	}
+	if err != nil {
+		m.setStatus(err, "")
+		return nil
+	}
+	m.stopEval()
+	m.engine.Close()
+	m.engine = eng
+	m.cache.clear()
+	m.setStatus(nil, "engine %s", eng.Describe())
+	return m.startEval()
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type explorerKeyMap struct {
+	up          key.Binding
+	down        key.Binding
+	fold        key.Binding
+	expandAll   key.Binding
+	collapseAll key.Binding
+	insert      key.Binding
+	cancel      key.Binding
+}
+
+func (k explorerKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.insert, k.cancel, k.fold, k.up, k.down}
+}
+
+func (k explorerKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp(), {k.expandAll, k.collapseAll}}
+}
+
+// explorer is an overlay for browsing the input document as a tree. It shows
+// the jq path of the value under the cursor and inserts it into the filter.
+type explorer struct {
+	tree *tree
+	keys explorerKeyMap
+	top  int
+}
+
+func newExplorer(content string) (*explorer, error) {
+	t, err := newTree(content, nil)
+	if err != nil {
+		return nil, errors.New("input is not JSON")
+	}
+	if len(t.lines) == 0 {
+		return nil, errors.New("no input to explore")
+	}
+	return &explorer{
+		tree: t,
+		keys: explorerKeyMap{
+			up: key.NewBinding(
+				key.WithKeys("up", "k", "ctrl+p"),
+				key.WithHelp("↑/k", "up"),
+			),
+			down: key.NewBinding(
+				key.WithKeys("down", "j", "ctrl+n"),
+				key.WithHelp("↓/j", "down"),
+			),
+			fold: key.NewBinding(
+				key.WithKeys(" "),
+				key.WithHelp("space", "fold"),
+			),
+			expandAll: key.NewBinding(
+				key.WithKeys("+"),
+				key.WithHelp("+", "expand all"),
+			),
+			collapseAll: key.NewBinding(
+				key.WithKeys("-"),
+				key.WithHelp("-", "collapse all"),
+			),
+			insert: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "insert path"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+g", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+		},
+	}, nil
+}
+
+func (e *explorer) keyMap() help.KeyMap {
+	return e.keys
+}
+
+func (e *explorer) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	t := e.tree
+	switch {
+	case key.Matches(msg, e.keys.cancel):
+		return true, nil
+	case key.Matches(msg, e.keys.insert):
+		return true, m.insertFilter(e.path())
+	case key.Matches(msg, e.keys.up):
+		t.cursor = max(t.cursor-1, 0)
+	case key.Matches(msg, e.keys.down):
+		t.cursor = min(t.cursor+1, len(t.lines)-1)
+	case key.Matches(msg, e.keys.fold):
+		t.toggle()
+	case key.Matches(msg, e.keys.expandAll):
+		t.expandAll()
+	case key.Matches(msg, e.keys.collapseAll):
+		t.collapseAll()
+	}
+	return false, nil
+}
+
+// path returns the jq path of the value under the cursor.
+func (e *explorer) path() string {
+	return jqPath(e.tree.node().path[1:])
+}
+
+// view renders the part of the tree around the cursor below a title line
+// showing the current path.
+func (e *explorer) view(width, height int) string {
+	rows := max(height-1, 1)
+	lines := strings.Split(strings.TrimSuffix(e.tree.render(), "\n"), "\n")
+	if c := e.tree.cursor; c < e.top {
+		e.top = c
+	} else if c >= e.top+rows {
+		e.top = c - rows + 1
+	}
+	e.top = max(min(e.top, len(lines)-rows), 0)
+	lines = lines[e.top:min(e.top+rows, len(lines))]
+	for i, l := range lines {
+		lines[i] = ansi.Truncate(l, width, "…")
+	}
+	title := _pickerTitle.Render("path explorer") + " " + e.path()
+	lines = append([]string{truncate(title, width)}, lines...)
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
+
+// insertFilter inserts s into the filter at the cursor, replacing the
+// filter if it is empty or the identity.
+func (m *model) insertFilter(s string) tea.Cmd {
+	v := m.filterValue()
+	if strings.TrimSpace(v) == "" || strings.TrimSpace(v) == "." {
+		return m.setFilter(s)
+	}
+	if m.multiline {
+		m.editor.InsertString(s)
+	} else {
+		rs, pos := []rune(v), m.textinput.Position()
+		m.textinput.SetValue(string(rs[:pos]) + s + string(rs[pos:]))
+		m.textinput.SetCursor(pos + len([]rune(s)))
+	}
+	if m.live {
+		return m.scheduleEval()
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// _followInterval is how long --follow waits for a file to grow once it
+// has read to its end.
+const _followInterval = 250 * time.Millisecond
+
+// Follower reads data appended to the input, like tail -f.
+type Follower struct {
+	ch chan followMsg
+	// partial holds the start of a record that is still being written.
+	partial string
+}
+
+// followMsg carries data read from the followed input. done is set once a
+// pipe is closed.
+type followMsg struct {
+	data string
+	err  error
+	done bool
+}
+
+// followEvalMsg carries the result of the filter for newly read records.
+type followEvalMsg struct {
+	id int
+	engine.Result
+}
+
+// startFollow reads r in the background. A regular file is polled for more
+// data at its end instead of stopping there.
+func startFollow(r io.Reader, poll bool) *Follower {
+	f := &Follower{ch: make(chan followMsg)}
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				f.ch <- followMsg{data: string(buf[:n])}
+			}
+			switch {
+			case errors.Is(err, io.EOF) && poll:
+				time.Sleep(_followInterval)
+			case err != nil:
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				f.ch <- followMsg{err: err, done: true}
+				return
+			}
+		}
+	}()
+	return f
+}
+
+func (f *Follower) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-f.ch
+	}
+}
+
+// records returns the complete JSON values read so far and their number,
+// keeping an incomplete one for later. If the data does not parse as JSON,
+// it is cut after the last full line so that jq reports the error.
+func (f *Follower) records(data string) (string, int) {
+	buf := f.partial + data
+	dec := json.NewDecoder(strings.NewReader(buf))
+	end, n := 0, 0
+	for {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == nil {
+			end = int(dec.InputOffset())
+			n++
+			continue
+		}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			end = max(end, strings.LastIndexByte(buf, '\n')+1)
+		}
+		break
+	}
+	f.partial = buf[end:]
+	return buf[:end], n
+}
+
+// followed appends the records read from the input and runs the filter of
+// the current result over them, unless the whole input has to be
+// evaluated again.
+func (m *model) followed(msg followMsg) tea.Cmd {
+	if msg.done {
+		if msg.err != nil {
+			m.setStatus(msg.err, "")
+		} else {
+			m.setStatus(nil, "end of input")
+		}
+		return nil
+	}
+	cmd := m.follow.wait()
+	records, n := m.follow.records(msg.data)
+	if records == "" {
+		return cmd
+	}
+	m.rootContent += records
+	m.rootRecords += n
+	if len(m.stages) > 0 {
+		m.setStatus(nil, "input grew, %s to reset to it", m.keys.resetInput.Help().Key)
+		return cmd
+	}
+	m.content += records
+	m.keyIndex = nil
+	m.records += n
+	switch {
+	case m.evaluating() || m.evalOptions.Slurp || m.evalOptions.NullInput || m.resultFilter == "":
+		// The running evaluation started before these records came in, and
+		// slurped input has to be read as a whole.
+		return tea.Batch(cmd, m.startEval())
+	case m.followEval:
+		m.followQueue += records
+		return cmd
+	}
+	return tea.Batch(cmd, m.evalFollow(records))
+}
+
+// evalFollow runs the filter of the current result over records.
+func (m *model) evalFollow(records string) tea.Cmd {
+	m.followEval = true
+	id, eng, filter, opts, timeout := m.evalID, m.engine, m.resultFilter, m.evalOptions, m.timeout
+	ctx, cancel := m.evalContext()
+	return func() tea.Msg {
+		defer cancel()
+		res := eng.Eval(ctx, records, filter, opts)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res = timedOut(timeout)
+		}
+		return followEvalMsg{id: id, Result: res}
+	}
+}
+
+// followEvaluated appends the output for new records to the result, and
+// evaluates the records that came in meanwhile.
+func (m *model) followEvaluated(msg followEvalMsg) tea.Cmd {
+	m.followEval = false
+	if msg.id != m.evalID {
+		// A full evaluation has started since, and it covers the records.
+		m.followQueue = ""
+		return nil
+	}
+	if msg.Errors != "" {
+		m.errText = msg.Errors
+		m.resize()
+	}
+	m.result += msg.Output
+	m.resultBytes += len(ansi.Strip(msg.Output))
+	m.resultLines += strings.Count(msg.Output, "\n")
+	m.updateYAML()
+	m.updateGron()
+	m.updateElements()
+	if !m.showOriginal {
+		m.updateView()
+		if m.autoScroll {
+			m.viewport.GotoBottom()
+		}
+	}
+	if m.followQueue == "" {
+		return nil
+	}
+	records := m.followQueue
+	m.followQueue = ""
+	return m.evalFollow(records)
+}
+
+func (m *model) toggleAutoScroll() {
+	m.autoScroll = !m.autoScroll
+	m.setStatus(nil, "auto-scroll %s", onOff(m.autoScroll))
+	if m.autoScroll {
+		m.viewport.GotoBottom()
+	}
+}
+
+// OpenFollow reads what the input file, or stdin, holds so far, with the
+// number of its records, and starts following it for more.
+func OpenFollow(files []string) (string, int, *Follower, error) {
+	if len(files) == 0 {
+		return "", 0, startFollow(os.Stdin, false), nil
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		return "", 0, nil, err
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return "", 0, nil, err
+	}
+	fl := startFollow(f, true)
+	records, n := fl.records(string(b))
+	return records, n, fl, nil
+}
@@ -0,0 +1,26 @@
+package tui
+
+import "testing"
+
+func TestFollowerRecords(t *testing.T) {
+	f := &Follower{}
+	steps := []struct {
+		data    string
+		want    string
+		n       int
+		partial string
+	}{
+		{"{\"a\":1}\n{\"a\"", "{\"a\":1}", 1, "\n{\"a\""},
+		{":2}\n3\n[4", "\n{\"a\":2}\n3", 2, "\n[4"},
+		{"]\n", "\n[4]", 1, "\n"},
+		{"{\"b\" 1}\n5\n", "\n{\"b\" 1}\n5\n", 0, ""},
+		{"", "", 0, ""},
+	}
+	for i, s := range steps {
+		got, n := f.records(s.data)
+		if got != s.want || n != s.n || f.partial != s.partial {
+			t.Errorf("step %d: records(%q) = %q, %d with %q left; want %q, %d with %q left",
+				i, s.data, got, n, f.partial, s.want, s.n, s.partial)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type lineJumpKeyMap struct {
+	confirm key.Binding
+	cancel  key.Binding
+}
+
+func (k lineJumpKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.confirm, k.cancel}
+}
+
+func (k lineJumpKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// lineJump is the prompt in the status bar that scrolls the result to a
+// line, like :N in vim.
+type lineJump struct {
+	input     textinput.Model
+	keys      lineJumpKeyMap
+	prompting bool
+}
+
+func newLineJump() lineJump {
+	ti := textinput.New()
+	ti.Prompt = ":"
+	// Only digits can be typed.
+	ti.Validate = func(s string) error {
+		if strings.Trim(s, "0123456789") != "" {
+			return errors.New("not a line number")
+		}
+		return nil
+	}
+	return lineJump{
+		input: ti,
+		keys: lineJumpKeyMap{
+			confirm: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "go to line"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+		},
+	}
+}
+
+func (j lineJump) keyMap() help.KeyMap {
+	return j.keys
+}
+
+// promptView renders the line number being typed along with the number
+// of lines to choose from.
+func (j lineJump) promptView(width, lines int) string {
+	info := fmt.Sprintf("of %s lines", formatCount(lines))
+	j.input.Width = max(width-lipgloss.Width(info)-3, 1)
+	in := j.input.View()
+	gap := max(width-lipgloss.Width(in)-lipgloss.Width(info), 1)
+	return in + strings.Repeat(" ", gap) + _statusInfo.Render(info)
+}
+
+// openLineJump starts typing a line of the result to scroll to.
+func (m *model) openLineJump() tea.Cmd {
+	m.lineJump.prompting = true
+	m.lineJump.input.SetValue("")
+	m.resize()
+	return m.lineJump.input.Focus()
+}
+
+// updateLineJump handles a key press while the line number is typed.
+func (m *model) updateLineJump(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.lineJump.keys.cancel):
+		m.closeLineJump()
+		return nil
+	case key.Matches(msg, m.lineJump.keys.confirm):
+		m.closeLineJump()
+		if n, err := strconv.Atoi(m.lineJump.input.Value()); err == nil {
+			m.gotoLine(n)
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	m.lineJump.input, cmd = m.lineJump.input.Update(msg)
+	return cmd
+}
+
+func (m *model) closeLineJump() {
+	m.lineJump.prompting = false
+	m.lineJump.input.Blur()
+	m.resize()
+}
+
+// gotoLine scrolls line n of the result, counted from 1, to the top of
+// the viewport, loading it first if --max-lines left it out. A number
+// past the end goes to the last line.
+func (m *model) gotoLine(n int) {
+	total := len(m.lines) + m.hiddenLines
+	if total == 0 {
+		return
+	}
+	line := min(max(n, 1), total) - 1
+	m.showLine(line)
+	m.viewport.SetYOffset(m.rowOf(line))
+}
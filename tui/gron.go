@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/itchyny/gojq"
+)
+
+// gron flattens the JSON values printed by jq into an assignment per value,
+// as gron does: json.items[0].name = "foo";. It returns the path of each
+// line too, the index of its document first.
+func gron(result string) (string, [][]any, error) {
+	t, err := newTree(result, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	var sb strings.Builder
+	var paths [][]any
+	var walk func(n *treeNode)
+	walk = func(n *treeNode) {
+		root := "json"
+		if len(t.roots) > 1 {
+			root = fmt.Sprintf("json[%d]", n.path[0])
+		}
+		root += gronPath(n.path[1:])
+		fmt.Fprintf(&sb, "%s = %s;\n", root, gronValue(n))
+		paths = append(paths, n.path)
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	for _, n := range t.roots {
+		walk(n)
+	}
+	return sb.String(), paths, nil
+}
+
+// gronPath returns path as gron writes it, with keys that are not
+// JavaScript identifiers in brackets, as in json["a-b"], so that the
+// output reads back as JavaScript.
+func gronPath(path []any) string {
+	var sb strings.Builder
+	for _, p := range path {
+		switch p := p.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", p)
+		case string:
+			if isJSIdent(p) {
+				sb.WriteString("." + p)
+			} else {
+				b, _ := gojq.Marshal(p)
+				sb.WriteString("[" + string(b) + "]")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// _jsReserved are the JavaScript reserved words, which gron does not
+// write as identifiers either.
+var _jsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true, "import": true,
+	"in": true, "instanceof": true, "new": true, "null": true, "return": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true,
+	"with": true, "yield": true,
+}
+
+// isJSIdent reports whether s is a JavaScript identifier, which gron
+// writes after a dot.
+func isJSIdent(s string) bool {
+	if s == "" || _jsReserved[s] {
+		return false
+	}
+	for i, r := range s {
+		start := r == '$' || r == '_' || unicode.In(r, unicode.Lu, unicode.Ll, unicode.Lt, unicode.Lm, unicode.Lo, unicode.Nl)
+		if !start && (i == 0 || !unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc)) {
+			return false
+		}
+	}
+	return true
+}
+
+func gronValue(n *treeNode) string {
+	switch {
+	case n.object:
+		return "{}"
+	case n.container:
+		return "[]"
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(n.value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// updateGron flattens the result while the gron view is on. A result that
+// is not JSON, such as raw strings, is shown as it is.
+func (m *model) updateGron() {
+	m.gronResult, m.gronPaths = "", nil
+	if !m.gron {
+		return
+	}
+	text, paths, err := gron(m.redacted(m.result))
+	if err != nil {
+		m.gronResult = m.redacted(m.result)
+		return
+	}
+	m.gronResult, m.gronPaths = text, paths
+}
+
+func (m *model) toggleGron() {
+	m.gron = !m.gron
+	m.updateGron()
+	m.setStatus(nil, "gron view %s", onOff(m.gron))
+	m.resetView()
+}
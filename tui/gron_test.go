@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGron(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   string
+	}{
+		{"scalar", "1\n", "json = 1;\n"},
+		{"object", `{"a":{"b":[true,null]}}`,
+			"json = {};\njson.a = {};\njson.a.b = [];\njson.a.b[0] = true;\njson.a.b[1] = null;\n"},
+		{"keys that are not identifiers", `{"a-b":1,"b c":2,"1x":3,"":4,"if":5}`,
+			"json = {};\njson[\"a-b\"] = 1;\njson[\"b c\"] = 2;\njson[\"1x\"] = 3;\njson[\"\"] = 4;\njson[\"if\"] = 5;\n"},
+		{"identifiers", `{"$x":1,"_y2":2,"é":3}`, "json = {};\njson.$x = 1;\njson._y2 = 2;\njson.é = 3;\n"},
+		{"quoted key", `{"a\"b":"<c>"}`, "json = {};\njson[\"a\\\"b\"] = \"<c>\";\n"},
+		{"documents", "1\n[2]\n", "json[0] = 1;\njson[1] = [];\njson[1][0] = 2;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := gron(tt.result)
+			if err != nil {
+				t.Fatalf("gron(%q): %v", tt.result, err)
+			}
+			if got != tt.want {
+				t.Errorf("gron(%q) =\n%s\nwant\n%s", tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGronPaths(t *testing.T) {
+	_, paths, err := gron(`{"a-b":[1]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]any{{0}, {0, "a-b"}, {0, "a-b", 0}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("gron paths = %v, want %v", paths, want)
+	}
+}
+
+func TestGronInvalid(t *testing.T) {
+	if _, _, err := gron("not json\n"); err == nil {
+		t.Error("gron of raw output succeeded, want an error")
+	}
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	_hlString   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	_hlNumber   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	_hlBuiltin  = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
+	_hlKeyword  = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
+	_hlVariable = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	_hlPipe     = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+	_hlComment  = lipgloss.NewStyle().Faint(true)
+	_hlBracket  = lipgloss.NewStyle().Bold(true).Underline(true)
+)
+
+var _keywords = map[string]bool{
+	"def": true, "if": true, "then": true, "elif": true, "else": true, "end": true, "as": true,
+	"reduce": true, "foreach": true, "try": true, "catch": true, "label": true, "import": true,
+	"include": true, "and": true, "or": true, "__loc__": true,
+}
+
+// _builtinNames holds the names of the functions in _builtins.
+var _builtinNames = func() map[string]bool {
+	names := make(map[string]bool, len(_builtins))
+	for _, sig := range _builtins {
+		name, _, _ := strings.Cut(sig, "(")
+		names[name] = true
+	}
+	return names
+}()
+
+// highlightFilter returns the style of every rune of a jq filter, nil for
+// plain text. The bracket at or before pos and its partner are marked.
+func highlightFilter(rs []rune, pos int) []*lipgloss.Style {
+	styles := make([]*lipgloss.Style, len(rs))
+	mark := func(from, to int, st *lipgloss.Style) {
+		for i := from; i < to; i++ {
+			styles[i] = st
+		}
+	}
+	word := func(i int) int {
+		for i < len(rs) && (isWordChar(rs[i]) || rs[i] == ':') {
+			i++
+		}
+		return i
+	}
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case r == '"':
+			j := i + 1
+			for j < len(rs) && rs[j] != '"' {
+				if rs[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(rs))
+			mark(i, j, &_hlString)
+			i = j
+		case r == '#':
+			mark(i, len(rs), &_hlComment)
+			i = len(rs)
+		case unicode.IsDigit(r) && (i == 0 || !isWordChar(rs[i-1])):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.' ||
+				rs[j] == 'e' || rs[j] == 'E' ||
+				(rs[j] == '-' || rs[j] == '+') && (rs[j-1] == 'e' || rs[j-1] == 'E')) {
+				j++
+			}
+			mark(i, j, &_hlNumber)
+			i = j
+		case r == '$' || r == '@':
+			j := word(i + 1)
+			if r == '$' {
+				mark(i, j, &_hlVariable)
+			} else {
+				mark(i, j, &_hlBuiltin)
+			}
+			i = max(j, i+1)
+		case isWordChar(r):
+			j := word(i)
+			w := string(rs[i:j])
+			switch {
+			case i > 0 && rs[i-1] == '.':
+			case _keywords[w]:
+				mark(i, j, &_hlKeyword)
+			case _builtinNames[w]:
+				mark(i, j, &_hlBuiltin)
+			}
+			i = j
+		case r == '|' && (i+1 == len(rs) || rs[i+1] != '='):
+			mark(i, i+1, &_hlPipe)
+			i++
+		default:
+			i++
+		}
+	}
+	if a, b, ok := matchBracket(rs, styles, pos); ok {
+		styles[a], styles[b] = &_hlBracket, &_hlBracket
+	}
+	return styles
+}
+
+// matchBracket finds the bracket under the cursor, or else right before
+// it, and its partner, skipping brackets in strings and comments.
+func matchBracket(rs []rune, styles []*lipgloss.Style, pos int) (int, int, bool) {
+	const open, closed = "([{", ")]}"
+	code := func(i int) bool {
+		return styles[i] != &_hlString && styles[i] != &_hlComment
+	}
+	at := -1
+	for _, i := range []int{pos, pos - 1} {
+		if i >= 0 && i < len(rs) && strings.ContainsRune(open+closed, rs[i]) && code(i) {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		return 0, 0, false
+	}
+	dir, depth := 1, 0
+	if strings.ContainsRune(closed, rs[at]) {
+		dir = -1
+	}
+	for i := at; i >= 0 && i < len(rs); i += dir {
+		if !code(i) {
+			continue
+		}
+		switch {
+		case strings.ContainsRune(open, rs[i]):
+			depth += dir
+		case strings.ContainsRune(closed, rs[i]):
+			depth -= dir
+		}
+		if depth == 0 {
+			return at, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// filterScroll returns the index of the first rune of the filter in view,
+// keeping the cursor on screen.
+func (m model) filterScroll() int {
+	avail := max(m.width-ansi.StringWidth(m.textinput.Prompt)-1, 1)
+	return max(m.textinput.Position()-avail+1, 0)
+}
+
+// filterView renders the one-line filter input with syntax highlighting,
+// scrolled so that the cursor is in view. A history suggestion is shown
+// after the cursor as in the plain text input.
+func (m model) filterView(prompt string) string {
+	ti := m.textinput
+	rs := []rune(ti.Value())
+	if len(rs) == 0 {
+		return ti.View()
+	}
+	pos := ti.Position()
+	styles := highlightFilter(rs, pos)
+	if m.syntaxErr != nil {
+		from, to := m.syntaxErrorRange()
+		for i := from; i < min(to, len(styles)); i++ {
+			styles[i] = &_syntaxToken
+		}
+		ti.PromptStyle = ti.PromptStyle.Foreground(lipgloss.Color("1"))
+	}
+	var ghost []rune
+	if s := ti.AvailableSuggestions(); ti.Focused() && len(s) > 0 && strings.HasPrefix(s[0], ti.Value()) {
+		ghost = []rune(s[0])[len(rs):]
+	}
+
+	start := m.filterScroll()
+	end := min(len(rs), start+max(m.width-ansi.StringWidth(prompt)-1, 1))
+
+	var sb strings.Builder
+	sb.WriteString(ti.PromptStyle.Render(prompt))
+	render := func(from, to int) {
+		for i := from; i < to; {
+			j := i + 1
+			for j < to && styles[j] == styles[i] {
+				j++
+			}
+			if st := styles[i]; st != nil {
+				sb.WriteString(st.Render(string(rs[i:j])))
+			} else {
+				sb.WriteString(ti.TextStyle.Render(string(rs[i:j])))
+			}
+			i = j
+		}
+	}
+	if !ti.Focused() || pos >= end {
+		render(start, end)
+	}
+	if !ti.Focused() {
+		return sb.String()
+	}
+	c := ti.Cursor
+	if pos < end {
+		render(start, pos)
+		if st := styles[pos]; st != nil {
+			c.TextStyle = *st
+		}
+		c.SetChar(string(rs[pos]))
+		sb.WriteString(c.View())
+		render(pos+1, end)
+		return sb.String()
+	}
+	if len(ghost) == 0 {
+		c.SetChar(" ")
+		sb.WriteString(c.View())
+		return sb.String()
+	}
+	c.TextStyle = ti.PlaceholderStyle
+	c.SetChar(string(ghost[0]))
+	sb.WriteString(c.View())
+	sb.WriteString(ti.PlaceholderStyle.Render(string(ghost[1:])))
+	return sb.String()
+}
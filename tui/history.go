@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHistorySize caps the number of filters kept in the history file.
+const DefaultHistorySize = 1000
+
+// History keeps the evaluated filters in order, oldest first, and a cursor
+// for stepping through them like a shell.
+type History struct {
+	entries []string
+	// pos indexes entries while browsing; len(entries) means the user is
+	// back at the line they were editing.
+	pos   int
+	draft string
+
+	// path is the file new entries are appended to; empty keeps the
+	// history in memory only.
+	path string
+	max  int
+}
+
+// DefaultHistoryPath returns $XDG_DATA_HOME/ijq/history, falling back to
+// ~/.local/share/ijq/history.
+func DefaultHistoryPath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "ijq", "history"), nil
+}
+
+// OpenHistory loads the history file from its default location.
+func OpenHistory(max int) (*History, error) {
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return loadHistory(path, max)
+}
+
+// loadHistory reads the history file at path, keeping at most max of the
+// newest entries. A missing file yields an empty history.
+func loadHistory(path string, max int) (*History, error) {
+	h := &History{path: path, max: max}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var filter string
+		if json.Unmarshal(sc.Bytes(), &filter) != nil || filter == "" {
+			continue
+		}
+		h.entries = append(h.entries, filter)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if max > 0 && len(h.entries) > max {
+		h.entries = h.entries[len(h.entries)-max:]
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	h.pos = len(h.entries)
+	return h, nil
+}
+
+// add records filter as the newest entry and resets browsing. Consecutive
+// duplicates are stored once.
+func (h *History) add(filter string) error {
+	h.pos = len(h.entries)
+	h.draft = ""
+	if n := len(h.entries); n > 0 && h.entries[n-1] == filter {
+		return nil
+	}
+	h.entries = append(h.entries, filter)
+	h.pos = len(h.entries)
+	if h.path == "" {
+		return nil
+	}
+	return appendHistory(h.path, filter)
+}
+
+// rewrite replaces the history file with the entries held in memory.
+func (h *History) rewrite() error {
+	var sb strings.Builder
+	for _, filter := range h.entries {
+		b, _ := json.Marshal(filter)
+		sb.Write(b)
+		sb.WriteByte('\n')
+	}
+	return os.WriteFile(h.path, []byte(sb.String()), 0o600)
+}
+
+func appendHistory(path, filter string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, _ := json.Marshal(filter)
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// prev steps back to an older entry. The line being edited is remembered so
+// that next can return to it.
+func (h *History) prev(current string) (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// next steps forward to a newer entry, ending at the line being edited.
+func (h *History) next() (string, bool) {
+	if h.pos >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+// suggest returns the newest entry that extends prefix, or "" if there is
+// none.
+func (h *History) suggest(prefix string) string {
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if e := h.entries[i]; len(e) > len(prefix) && strings.HasPrefix(e, prefix) {
+			return e
+		}
+	}
+	return ""
+}
+
+// recent returns the distinct entries, newest first.
+func (h *History) recent() []string {
+	seen := make(map[string]bool, len(h.entries))
+	var out []string
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if e := h.entries[i]; !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readLines returns the lines of the file at path.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func TestLoadHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		max      int
+		want     []string
+		wantFile []string
+	}{
+		{"entries", `".a"` + "\n" + `".b | length"` + "\n", 10, []string{".a", ".b | length"}, nil},
+		{"multiline filter", `".a\n| .b"` + "\n", 10, []string{".a\n| .b"}, nil},
+		{"bad lines", `".a"` + "\nnot json\n\"\"\n42\n" + `".b"` + "\n", 10, []string{".a", ".b"}, nil},
+		{"trimmed", `"1"` + "\n" + `"2"` + "\n" + `"3"` + "\n" + `"4"` + "\n", 2, []string{"3", "4"}, []string{`"3"`, `"4"`}},
+		{"no limit", `"1"` + "\n" + `"2"` + "\n", 0, []string{"1", "2"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			h, err := loadHistory(path, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(h.entries, tt.want) {
+				t.Errorf("entries = %q, want %q", h.entries, tt.want)
+			}
+			if h.pos != len(h.entries) {
+				t.Errorf("pos = %d, want %d", h.pos, len(h.entries))
+			}
+			if tt.wantFile != nil {
+				if got := readLines(t, path); !slices.Equal(got, tt.wantFile) {
+					t.Errorf("file after trimming = %q, want %q", got, tt.wantFile)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadHistoryMissing(t *testing.T) {
+	h, err := loadHistory(filepath.Join(t.TempDir(), "none"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.entries) != 0 || h.pos != 0 {
+		t.Errorf("missing file loaded %q at %d, want an empty history", h.entries, h.pos)
+	}
+}
+
+func TestHistoryAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ijq", "history")
+	h, err := loadHistory(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filter := range []string{".a", ".a", ".b", ".a", "x\ny"} {
+		if err := h.add(filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{".a", ".b", ".a", "x\ny"}
+	if !slices.Equal(h.entries, want) {
+		t.Errorf("entries = %q, want %q", h.entries, want)
+	}
+	if got, wantFile := readLines(t, path), []string{`".a"`, `".b"`, `".a"`, `"x\ny"`}; !slices.Equal(got, wantFile) {
+		t.Errorf("file = %q, want %q", got, wantFile)
+	}
+	for _, p := range []struct {
+		path string
+		mode os.FileMode
+	}{{path, 0o600}, {filepath.Dir(path), 0o700}} {
+		fi, err := os.Stat(p.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := fi.Mode().Perm(); mode != p.mode {
+			t.Errorf("%s has mode %v, want %v", p.path, mode, p.mode)
+		}
+	}
+	reloaded, err := loadHistory(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(reloaded.entries, want) {
+		t.Errorf("reloaded entries = %q, want %q", reloaded.entries, want)
+	}
+}
+
+func TestHistoryInMemory(t *testing.T) {
+	h := &History{}
+	if err := h.add(".a"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(h.entries, []string{".a"}) {
+		t.Errorf("entries = %q, want [.a]", h.entries)
+	}
+}
+
+func TestHistoryBrowse(t *testing.T) {
+	h := &History{entries: []string{".a", ".b"}, pos: 2}
+	steps := []struct {
+		prev bool
+		want string
+		ok   bool
+	}{
+		{true, ".b", true},
+		{true, ".a", true},
+		{true, "", false},
+		{false, ".b", true},
+		{false, ".dra", true},
+		{false, "", false},
+	}
+	for i, s := range steps {
+		var (
+			got string
+			ok  bool
+		)
+		if s.prev {
+			got, ok = h.prev(".dra")
+		} else {
+			got, ok = h.next()
+		}
+		if got != s.want || ok != s.ok {
+			t.Errorf("step %d = %q, %v; want %q, %v", i, got, ok, s.want, s.ok)
+		}
+	}
+	// Adding resets browsing, and the draft with it.
+	h.prev(".c")
+	if err := h.add(".c"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := h.prev(""); got != ".c" {
+		t.Errorf("prev after add = %q, want .c", got)
+	}
+}
+
+func TestHistorySuggestAndRecent(t *testing.T) {
+	h := &History{entries: []string{".items[]", ".items | length", ".name", ".items[]"}}
+	for _, tt := range []struct{ prefix, want string }{
+		{".i", ".items[]"},
+		{".items |", ".items | length"},
+		{".name", ""},
+		{".x", ""},
+	} {
+		if got := h.suggest(tt.prefix); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+	if got, want := h.recent(), []string{".items[]", ".name", ".items | length"}; !slices.Equal(got, want) {
+		t.Errorf("recent() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// _streamPath matches a filter that starts by iterating over an array or
+// object at a path of plain keys, such as .[] or .items[] | .name,
+// capturing the path and the rest of the filter.
+var _streamPath = regexp.MustCompile(`(?s)^\s*((?:\.[A-Za-z_][A-Za-z0-9_]*)*)\s*\.?\[\]\s*(?:\|(.*))?$`)
+
+// streamFilter rewrites filter for --stream with --null-input, so that
+// the values it iterates over are rebuilt one at a time with the usual
+// fromstream(n|truncate_stream(...)) pattern, instead of the whole
+// document at once.
+func streamFilter(filter string) string {
+	m := _streamPath.FindStringSubmatch(filter)
+	if m == nil {
+		if filter = strings.TrimSpace(filter); filter == "" || filter == "." {
+			return "fromstream(inputs)"
+		}
+		return "fromstream(inputs) | " + filter
+	}
+	var keys []string
+	if m[1] != "" {
+		for _, k := range strings.Split(m[1], ".")[1:] {
+			keys = append(keys, strconv.Quote(k))
+		}
+	}
+	events := "inputs"
+	if len(keys) > 0 {
+		events = fmt.Sprintf("inputs | select(.[0][:%d] == [%s])", len(keys), strings.Join(keys, ","))
+	}
+	wrapped := fmt.Sprintf("fromstream(%d|truncate_stream(%s))", len(keys)+1, events)
+	if rest := strings.TrimSpace(m[2]); rest != "" {
+		wrapped += " | " + rest
+	}
+	return wrapped
+}
+
+// toggleStream turns jq's --stream option on or off.
+func (m *model) toggleStream() tea.Cmd {
+	m.evalOptions.Stream = !m.evalOptions.Stream
+	m.setStatus(nil, "stream %s", onOff(m.evalOptions.Stream))
+	return m.startEval()
+}
+
+// wrapStream rewrites the filter with streamFilter and turns on --stream
+// and --null-input for it.
+func (m *model) wrapStream() tea.Cmd {
+	m.evalOptions.Stream, m.evalOptions.NullInput = true, true
+	m.setFilterValue(streamFilter(m.filterValue()))
+	m.setStatus(nil, "wrapped filter for --stream")
+	return m.startEval()
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// pane is where a key binding works.
+type pane int
+
+const (
+	paneAny pane = iota
+	paneFilter
+	paneResult
+	paneTree
+)
+
+// helpGroup is a titled set of bindings in the help overlay.
+type helpGroup struct {
+	title    string
+	pane     pane
+	bindings []key.Binding
+}
+
+// helpGroups returns every binding, grouped by the pane it works in. Both
+// FullHelp and the help overlay are generated from it, and the command
+// palette lists its actions from it.
+func (k KeyMap) helpGroups() []helpGroup {
+	return []helpGroup{
+		{"General", paneAny, []key.Binding{k.quit, k.quitWith, k.focusNextPane, k.leaveInput, k.enterInput, k.showHelp, k.palette, k.suspend, k.copyResult, k.copyFilter, k.saveResult}},
+		{"Filter", paneFilter, []key.Binding{k.eval, k.toggleMultiline, k.evalProgram, k.openEditor, k.toggleLive, k.logResult, k.historyPrev, k.historyNext, k.searchHistory, k.acceptSuggest, k.saveSnippet, k.snippets}},
+		{"Editing", paneFilter, []key.Binding{k.editing.lineStart, k.editing.lineEnd, k.editing.wordBackward, k.editing.wordForward, k.editing.deleteWordBackward, k.editing.deleteWordForward, k.editing.deleteBeforeCursor, k.editing.deleteAfterCursor}},
+		{"Output", paneAny, []key.Binding{k.toggleRaw, k.toggleCompact, k.toggleSortKeys, k.cycleIndent, k.toggleSlurp, k.toggleStream, k.wrapStream, k.toggleYAML, k.toggleRedact, k.editVars, k.explorePaths}},
+		{"Result", paneResult, []key.Binding{k.viewport.Down, k.viewport.Up, k.viewport.PageDown, k.viewport.PageUp, k.viewport.HalfPageDown, k.viewport.HalfPageUp, k.gotoTop, k.gotoBottom, k.gotoLine, k.scrollLeft, k.scrollRight, k.search, k.nextMatch, k.prevMatch, k.lineNumbers, k.toggleWrap, k.toggleGron, k.toggleElements, k.prevElement, k.nextElement, k.showSchema, k.loadMore, k.autoScroll, k.copyPath, k.viewOriginal, k.toggleDiff, k.treeView}},
+		{"Tree view", paneTree, []key.Binding{k.toggleFold, k.expandAll, k.collapseAll}},
+		{"Split view", paneAny, []key.Binding{k.toggleSplit, k.narrowSplit, k.widenSplit, k.togglePin}},
+		{"Tabs and stages", paneAny, []key.Binding{k.newTab, k.prevTab, k.nextTab, k.resultBack, k.resultForward, k.prevDocument, k.nextDocument, k.pushStage, k.popStage, k.drillDown, k.resetInput, k.reload}},
+	}
+}
+
+type helpKeyMap struct {
+	close    key.Binding
+	viewport viewport.KeyMap
+}
+
+func (k helpKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.close, k.viewport.Down, k.viewport.Up, k.viewport.PageDown, k.viewport.PageUp}
+}
+
+func (k helpKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// helpOverlay lists every key binding over the whole screen, scrolling
+// when they do not fit.
+type helpOverlay struct {
+	keys  helpKeyMap
+	lines []string
+	pager pager
+}
+
+func newHelpOverlay(groups []helpGroup, keys viewport.KeyMap) *helpOverlay {
+	width := 0
+	for _, g := range groups {
+		for _, b := range g.bindings {
+			if b.Enabled() {
+				width = max(width, ansi.StringWidth(b.Help().Key))
+			}
+		}
+	}
+	var lines []string
+	for _, g := range groups {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, _pickerTitle.Render(g.title))
+		for _, b := range g.bindings {
+			if !b.Enabled() {
+				continue
+			}
+			k := b.Help().Key
+			lines = append(lines, fmt.Sprintf("  %s%s  %s", k, strings.Repeat(" ", width-ansi.StringWidth(k)), b.Help().Desc))
+		}
+	}
+	h := &helpOverlay{
+		keys: helpKeyMap{
+			close: key.NewBinding(
+				key.WithKeys("esc", "q", "?", "f1", "ctrl+c"),
+				key.WithHelp("esc", "close"),
+			),
+			viewport: keys,
+		},
+		lines: lines,
+		pager: newPager(0, 0),
+	}
+	h.pager.KeyMap = keys
+	h.pager.setRows(len(lines))
+	return h
+}
+
+func (h *helpOverlay) keyMap() help.KeyMap {
+	return h.keys
+}
+
+func (h *helpOverlay) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	if key.Matches(msg, h.keys.close) {
+		return true, nil
+	}
+	h.pager, cmd = h.pager.Update(msg)
+	return false, cmd
+}
+
+// view sizes the pager to the screen it is given, so that paging moves by
+// what is shown.
+func (h *helpOverlay) view(width, height int) string {
+	h.pager.Width, h.pager.Height = width, height
+	h.pager.SetYOffset(h.pager.YOffset)
+	end := min(h.pager.YOffset+height, len(h.lines))
+	return h.pager.view(h.lines[h.pager.YOffset:end])
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// _pathChain matches the path expression, such as .items[].metadata, that
+// ends right before the key being completed.
+var _pathChain = regexp.MustCompile(`(?:\.[A-Za-z_][A-Za-z0-9_]*|\."(?:[^"\\]|\\.)*"|\[[^\[\]]*\])*$`)
+
+// keyIndex maps the path of every object in the input, with array indices
+// left out as in .items[].metadata, to its keys.
+type keyIndex map[string][]string
+
+// newKeyIndex collects the object keys of the JSON values in content.
+func newKeyIndex(content string) keyIndex {
+	ix := keyIndex{}
+	seen := map[string]map[string]bool{}
+	var walk func(path string, v any)
+	walk = func(path string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if seen[path] == nil {
+				seen[path] = map[string]bool{}
+			}
+			for k, x := range v {
+				if !seen[path][k] {
+					seen[path][k] = true
+					ix[path] = append(ix[path], k)
+				}
+				walk(path+"."+k, x)
+			}
+		case []any:
+			for _, x := range v {
+				walk(path+"[]", x)
+			}
+		}
+	}
+	dec := json.NewDecoder(strings.NewReader(content))
+	for {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+		walk("", v)
+	}
+	for _, keys := range ix {
+		slices.Sort(keys)
+	}
+	return ix
+}
+
+// complete returns the keys starting with word of the objects at the end
+// of chain. If chain is not a path into the input, as after a pipe, the
+// keys of every object are offered.
+func (ix keyIndex) complete(chain, word string) []completion {
+	keys, ok := ix[normalizeChain(chain)]
+	if !ok {
+		all := map[string]bool{}
+		for _, ks := range ix {
+			for _, k := range ks {
+				all[k] = true
+			}
+		}
+		for k := range all {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+	}
+	var items []completion
+	for _, k := range keys {
+		if !strings.HasPrefix(k, word) || k == word {
+			continue
+		}
+		text := k
+		if !isIdent(k) {
+			// As a JSON string, which jq reads, unlike Go escapes such as
+			// \x1b.
+			b, _ := gojq.Marshal(k)
+			text = string(b)
+		}
+		items = append(items, completion{text: text, label: "." + text})
+	}
+	return items
+}
+
+// normalizeChain converts a path expression to the form used by keyIndex:
+// array indices and slices become [] and quoted keys are unquoted.
+func normalizeChain(chain string) string {
+	var sb strings.Builder
+	for chain != "" {
+		switch {
+		case strings.HasPrefix(chain, `."`), strings.HasPrefix(chain, `["`):
+			end := 2
+			for end < len(chain) && chain[end] != '"' {
+				if chain[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			var k string
+			if err := json.Unmarshal([]byte(chain[1:min(end+1, len(chain))]), &k); err != nil {
+				return sb.String()
+			}
+			sb.WriteString("." + k)
+			chain = strings.TrimPrefix(chain[end+1:], "]")
+		case chain[0] == '[':
+			end := strings.IndexByte(chain, ']')
+			if end < 0 {
+				return sb.String()
+			}
+			sb.WriteString("[]")
+			chain = chain[end+1:]
+		default:
+			chain = chain[1:]
+			i := strings.IndexAny(chain, ".[")
+			if i < 0 {
+				i = len(chain)
+			}
+			sb.WriteString("." + chain[:i])
+			chain = chain[i:]
+		}
+	}
+	return sb.String()
+}
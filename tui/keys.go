@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Key binding presets, which the [keys] table of the config file can change
+// further.
+const (
+	KeysDefault = "default"
+	KeysVim     = "vim"
+)
+
+// KeyPresets are the names of the key binding presets, for --keys.
+var KeyPresets = []string{KeysDefault, KeysVim}
+
+// _keyTypes maps the names bubbletea gives keys other than printable
+// characters, such as "tab", "ctrl+k" or "pgdown", to the keys.
+var _keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{}
+	for t := tea.KeyType(-256); t < 256; t++ {
+		if s := t.String(); s != "" && t != tea.KeyRunes {
+			types[s] = t
+		}
+	}
+	return types
+}()
+
+// _arrows shortens the names of the arrow keys in the help bar.
+var _arrows = map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→"}
+
+// bindings returns the bindings by the names the [keys] table of the
+// config file uses for them.
+func (k *KeyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":                 &k.quit,
+		"quit-with":            &k.quitWith,
+		"focus-next-pane":      &k.focusNextPane,
+		"eval":                 &k.eval,
+		"eval-program":         &k.evalProgram,
+		"log-result":           &k.logResult,
+		"toggle-live":          &k.toggleLive,
+		"search":               &k.search,
+		"next-match":           &k.nextMatch,
+		"prev-match":           &k.prevMatch,
+		"history-prev":         &k.historyPrev,
+		"history-next":         &k.historyNext,
+		"search-history":       &k.searchHistory,
+		"accept-suggest":       &k.acceptSuggest,
+		"toggle-raw":           &k.toggleRaw,
+		"toggle-compact":       &k.toggleCompact,
+		"toggle-sort-keys":     &k.toggleSortKeys,
+		"cycle-indent":         &k.cycleIndent,
+		"toggle-yaml":          &k.toggleYAML,
+		"toggle-slurp":         &k.toggleSlurp,
+		"toggle-stream":        &k.toggleStream,
+		"wrap-stream":          &k.wrapStream,
+		"edit-vars":            &k.editVars,
+		"line-numbers":         &k.lineNumbers,
+		"toggle-wrap":          &k.toggleWrap,
+		"toggle-redact":        &k.toggleRedact,
+		"toggle-gron":          &k.toggleGron,
+		"show-schema":          &k.showSchema,
+		"scroll-left":          &k.scrollLeft,
+		"scroll-right":         &k.scrollRight,
+		"explore-paths":        &k.explorePaths,
+		"copy-path":            &k.copyPath,
+		"copy-result":          &k.copyResult,
+		"copy-filter":          &k.copyFilter,
+		"save-result":          &k.saveResult,
+		"toggle-multiline":     &k.toggleMultiline,
+		"open-editor":          &k.openEditor,
+		"save-snippet":         &k.saveSnippet,
+		"snippets":             &k.snippets,
+		"view-original":        &k.viewOriginal,
+		"toggle-diff":          &k.toggleDiff,
+		"push-stage":           &k.pushStage,
+		"pop-stage":            &k.popStage,
+		"drill-down":           &k.drillDown,
+		"reset-input":          &k.resetInput,
+		"load-more":            &k.loadMore,
+		"reload":               &k.reload,
+		"auto-scroll":          &k.autoScroll,
+		"new-tab":              &k.newTab,
+		"prev-tab":             &k.prevTab,
+		"next-tab":             &k.nextTab,
+		"result-back":          &k.resultBack,
+		"result-forward":       &k.resultForward,
+		"prev-document":        &k.prevDocument,
+		"next-document":        &k.nextDocument,
+		"toggle-pin":           &k.togglePin,
+		"toggle-split":         &k.toggleSplit,
+		"narrow-split":         &k.narrowSplit,
+		"widen-split":          &k.widenSplit,
+		"tree-view":            &k.treeView,
+		"toggle-fold":          &k.toggleFold,
+		"expand-all":           &k.expandAll,
+		"collapse-all":         &k.collapseAll,
+		"leave-input":          &k.leaveInput,
+		"enter-input":          &k.enterInput,
+		"goto-top":             &k.gotoTop,
+		"goto-bottom":          &k.gotoBottom,
+		"goto-line":            &k.gotoLine,
+		"toggle-elements":      &k.toggleElements,
+		"prev-element":         &k.prevElement,
+		"next-element":         &k.nextElement,
+		"show-help":            &k.showHelp,
+		"palette":              &k.palette,
+		"suspend":              &k.suspend,
+		"line-start":           &k.editing.lineStart,
+		"line-end":             &k.editing.lineEnd,
+		"word-backward":        &k.editing.wordBackward,
+		"word-forward":         &k.editing.wordForward,
+		"delete-word-backward": &k.editing.deleteWordBackward,
+		"delete-word-forward":  &k.editing.deleteWordForward,
+		"delete-before-cursor": &k.editing.deleteBeforeCursor,
+		"delete-after-cursor":  &k.editing.deleteAfterCursor,
+		"line-up":              &k.viewport.Up,
+		"line-down":            &k.viewport.Down,
+		"page-up":              &k.viewport.PageUp,
+		"page-down":            &k.viewport.PageDown,
+		"half-page-up":         &k.viewport.HalfPageUp,
+		"half-page-down":       &k.viewport.HalfPageDown,
+	}
+}
+
+// Vim changes k to the vim preset: esc leaves the filter for the result
+// rather than quitting, i goes back to it, and gg and G jump to the top
+// and bottom of the result.
+func (k *KeyMap) Vim() {
+	k.quit = key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "cancel"))
+	k.leaveInput = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "normal mode"))
+	k.enterInput = key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "insert mode"))
+	k.viewOriginal = key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "view input"))
+	k.gotoTop = key.NewBinding(key.WithKeys("g"), key.WithHelp("gg", "top"))
+	k.gotoBottom = key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "bottom"))
+	k.topTwice = true
+}
+
+// Remap replaces the keys of the named bindings, and checks that no key is
+// left doing two things at once. An empty list unbinds an action.
+func (k *KeyMap) Remap(keys map[string][]string) error {
+	bindings := k.bindings()
+	for _, name := range slices.Sorted(maps.Keys(keys)) {
+		b, ok := bindings[name]
+		if !ok {
+			return fmt.Errorf("keys: unknown action %q", name)
+		}
+		ks := keys[name]
+		if len(ks) == 0 {
+			if name == "quit" {
+				return fmt.Errorf("keys: quit needs a key")
+			}
+			b.Unbind()
+			continue
+		}
+		labels := make([]string, len(ks))
+		for i, s := range ks {
+			if s == "space" {
+				s, ks[i] = " ", " "
+			}
+			if !validKey(s) {
+				return fmt.Errorf("keys: %s: unknown key %q", name, s)
+			}
+			labels[i] = keyLabel(s)
+		}
+		desc := b.Help().Desc
+		*b = key.NewBinding(key.WithKeys(ks...), key.WithHelp(strings.Join(labels, "/"), desc))
+	}
+	return k.conflicts()
+}
+
+// validKey reports whether s is a key as bubbletea names it: a printable
+// character or a named key, either of them optionally with alt.
+func validKey(s string) bool {
+	s = strings.TrimPrefix(s, "alt+")
+	if r, n := utf8.DecodeRuneInString(s); n == len(s) && r != utf8.RuneError {
+		return unicode.IsPrint(r)
+	}
+	_, ok := _keyTypes[s]
+	return ok
+}
+
+// keyMsg returns the key press that bubbletea names s, the reverse of
+// tea.KeyMsg.String.
+func keyMsg(s string) tea.KeyMsg {
+	k := tea.Key{Type: tea.KeyRunes}
+	if rest, ok := strings.CutPrefix(s, "alt+"); ok && rest != "" {
+		k.Alt, s = true, rest
+	}
+	if t, ok := _keyTypes[s]; ok {
+		k.Type = t
+	} else {
+		k.Runes = []rune(s)
+	}
+	return tea.KeyMsg(k)
+}
+
+// keyLabel returns how the help bar shows key s, such as ctrl+← for
+// ctrl+left.
+func keyLabel(s string) string {
+	if s == " " {
+		return "space"
+	}
+	i := strings.LastIndex(s[:len(s)-1], "+") + 1
+	if a, ok := _arrows[s[i:]]; ok {
+		return s[:i] + a
+	}
+	return s
+}
+
+// conflicts returns an error for a key bound to two actions that can be
+// reached at the same time. The keys that work everywhere come before
+// those of the viewport and the tree; eval and the history keys hand
+// over to the viewport while it has focus, so they may share its keys.
+func (k *KeyMap) conflicts() error {
+	global := []string{
+		"quit", "quit-with", "focus-next-pane", "save-snippet", "snippets", "open-editor",
+		"toggle-multiline", "search-history", "toggle-raw", "toggle-compact", "toggle-sort-keys",
+		"cycle-indent", "toggle-slurp", "toggle-stream", "wrap-stream", "toggle-yaml", "edit-vars",
+		"toggle-live", "line-numbers", "toggle-wrap", "toggle-redact", "explore-paths", "save-result",
+		"copy-result", "copy-filter", "push-stage", "pop-stage", "drill-down", "reset-input", "new-tab",
+		"prev-tab", "next-tab", "result-back", "result-forward", "prev-document", "next-document",
+		"toggle-pin", "toggle-diff", "toggle-split", "narrow-split", "widen-split", "tree-view",
+		"auto-scroll", "log-result", "leave-input", "show-help", "palette", "suspend",
+	}
+	handover := []string{"eval", "eval-program", "history-prev", "history-next"}
+	editing := []string{
+		"accept-suggest", "line-start", "line-end", "word-backward", "word-forward",
+		"delete-word-backward", "delete-word-forward", "delete-before-cursor", "delete-after-cursor",
+	}
+	viewport := []string{
+		"search", "next-match", "prev-match", "copy-path", "view-original", "load-more", "reload",
+		"scroll-left", "scroll-right", "line-up", "line-down", "page-up", "page-down",
+		"half-page-up", "half-page-down", "enter-input", "goto-top", "goto-bottom", "goto-line",
+		"toggle-elements", "prev-element", "next-element", "toggle-gron", "show-schema",
+	}
+	tree := []string{"toggle-fold", "expand-all", "collapse-all"}
+
+	bindings := k.bindings()
+	for _, names := range [][]string{
+		slices.Concat(global, handover, editing),
+		slices.Concat(global, viewport),
+		slices.Concat(global, tree),
+	} {
+		seen := map[string]string{}
+		for _, name := range names {
+			for _, s := range bindings[name].Keys() {
+				if other, ok := seen[s]; ok {
+					return fmt.Errorf("keys: %q is bound to both %s and %s", s, other, name)
+				}
+				seen[s] = name
+			}
+		}
+	}
+	return nil
+}
+
+// bound reports whether msg is one of b's keys, like key.Matches but also
+// while b is disabled, which only hides it from the help.
+func bound(msg tea.KeyMsg, b key.Binding) bool {
+	return slices.Contains(b.Keys(), msg.String())
+}
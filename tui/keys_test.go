@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestKeyPresets(t *testing.T) {
+	k := DefaultKeyMap()
+	if err := k.conflicts(); err != nil {
+		t.Errorf("default keys: %v", err)
+	}
+	k.Vim()
+	if err := k.conflicts(); err != nil {
+		t.Errorf("vim keys: %v", err)
+	}
+}
+
+func TestRemap(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string][]string
+		action   string
+		wantKeys []string
+		wantHelp string
+	}{
+		{"rebind", map[string][]string{"toggle-raw": {"ctrl+g"}}, "toggle-raw", []string{"ctrl+g"}, "ctrl+g"},
+		{"several keys", map[string][]string{"quit": {"ctrl+q", "esc"}}, "quit", []string{"ctrl+q", "esc"}, "ctrl+q/esc"},
+		{"space", map[string][]string{"toggle-fold": {"space"}}, "toggle-fold", []string{" "}, "space"},
+		{"arrow", map[string][]string{"prev-tab": {"alt+up"}}, "prev-tab", []string{"alt+up"}, "alt+↑"},
+		{"unbind", map[string][]string{"toggle-raw": {}}, "toggle-raw", nil, ""},
+		{"swap", map[string][]string{"toggle-raw": {"alt+c"}, "toggle-compact": {"alt+r"}}, "toggle-compact", []string{"alt+r"}, "alt+r"},
+		{"moved", map[string][]string{"toggle-raw": {"alt+c"}, "toggle-compact": {}}, "toggle-raw", []string{"alt+c"}, "alt+c"},
+		{"viewport shares eval key", map[string][]string{"search": {"enter"}}, "search", []string{"enter"}, "enter"},
+		{"readline ctrl+k", map[string][]string{"palette": {"alt+x"}, "delete-after-cursor": {"ctrl+k"}},
+			"delete-after-cursor", []string{"ctrl+k"}, "ctrl+k"},
+		{"readline ctrl+e", map[string][]string{"open-editor": {"ctrl+x"}, "line-end": {"end", "ctrl+e"}},
+			"line-end", []string{"end", "ctrl+e"}, "end/ctrl+e"},
+		{"editing shares viewport key", map[string][]string{"line-start": {"g"}}, "line-start", []string{"g"}, "g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := DefaultKeyMap()
+			if err := k.Remap(tt.keys); err != nil {
+				t.Fatalf("Remap(%v): %v", tt.keys, err)
+			}
+			b := k.bindings()[tt.action]
+			if !slices.Equal(b.Keys(), tt.wantKeys) || b.Help().Key != tt.wantHelp {
+				t.Errorf("Remap(%v) bound %s to %q shown as %q, want %q shown as %q",
+					tt.keys, tt.action, b.Keys(), b.Help().Key, tt.wantKeys, tt.wantHelp)
+			}
+			if tt.wantHelp != "" && b.Help().Desc == "" {
+				t.Errorf("Remap(%v) dropped the description of %s", tt.keys, tt.action)
+			}
+		})
+	}
+}
+
+func TestRemapErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		keys map[string][]string
+		want string
+	}{
+		{"unknown action", map[string][]string{"nope": {"x"}}, `keys: unknown action "nope"`},
+		{"unknown key", map[string][]string{"toggle-raw": {"ctrl+nope"}}, `keys: toggle-raw: unknown key "ctrl+nope"`},
+		{"control character", map[string][]string{"toggle-raw": {"\x01"}}, `keys: toggle-raw: unknown key "\x01"`},
+		{"quit unbound", map[string][]string{"quit": {}}, "keys: quit needs a key"},
+		{"two global actions", map[string][]string{"toggle-raw": {"alt+c"}}, `keys: "alt+c" is bound to both toggle-raw and toggle-compact`},
+		{"global and viewport", map[string][]string{"toggle-raw": {"y"}}, `keys: "y" is bound to both toggle-raw and copy-path`},
+		{"global and editing", map[string][]string{"line-start": {"alt+r"}}, `keys: "alt+r" is bound to both toggle-raw and line-start`},
+		{"global and tree", map[string][]string{"toggle-fold": {"alt+r"}}, `keys: "alt+r" is bound to both toggle-raw and toggle-fold`},
+		{"palette and editing", map[string][]string{"delete-after-cursor": {"ctrl+k"}}, `keys: "ctrl+k" is bound to both palette and delete-after-cursor`},
+		{"editor and editing", map[string][]string{"line-end": {"ctrl+e"}}, `keys: "ctrl+e" is bound to both open-editor and line-end`},
+		{"eval and editing", map[string][]string{"accept-suggest": {"enter"}}, `keys: "enter" is bound to both eval and accept-suggest`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := DefaultKeyMap()
+			err := k.Remap(tt.keys)
+			if err == nil {
+				t.Fatalf("Remap(%v) succeeded, want an error", tt.keys)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Remap(%v) error %q, want it to contain %q", tt.keys, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyMsg(t *testing.T) {
+	for _, s := range []string{"a", "A", "?", " ", "é", "alt+a", "alt+<", "ctrl+k", "alt+enter", "up", "ctrl+left", "f1", "pgdown"} {
+		if got := keyMsg(s).String(); got != s {
+			t.Errorf("keyMsg(%q).String() = %q", s, got)
+		}
+		if !validKey(s) {
+			t.Errorf("validKey(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "ab", "ctrl+nope", "alt+", "\t"} {
+		if validKey(s) {
+			t.Errorf("validKey(%q) = true, want false", s)
+		}
+	}
+}
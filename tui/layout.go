@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+var _gutter = lipgloss.NewStyle().Faint(true)
+
+// _scrollStep is how many columns left and right scroll the result.
+const _scrollStep = 8
+
+// DefaultMaxLines is how many lines of the result are shown at first.
+const DefaultMaxLines = 5000
+
+// layout splits content into the lines shown in the viewport and counts
+// the rows they take: with wrapping on, a long line takes several rows.
+// It records the first row of every wrapped line in m.rows so that
+// search can find a line after wrapping. The rows are only rendered when
+// they scroll into view. Lines beyond m.lineLimit are left out, with a
+// row in their place that tells how many there are. A result taller than
+// the viewport gets a scrollbar, which takes its last column. The mouse
+// selection is dropped, as the lines it refers to may have changed.
+func (m *model) layout(content string) {
+	m.selection = selection{}
+	m.lines, m.rows = m.lines[:0], m.rows[:0]
+	m.maxLineWidth = -1
+	if content != "" {
+		m.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	m.hiddenLines = 0
+	if m.lineLimit > 0 && len(m.lines) > m.lineLimit {
+		m.hiddenLines = len(m.lines) - m.lineLimit
+		m.lines = m.lines[:m.lineLimit]
+	}
+	m.digits = 0
+	if m.lineNumbers {
+		m.digits = len(strconv.Itoa(len(m.lines)))
+	}
+	more := 0
+	if m.hiddenLines > 0 {
+		more = 1
+	}
+	m.viewport.setRows(len(m.lines) + more)
+	m.bar = m.overflows()
+	m.textWidth = m.viewport.Width
+	if m.digits > 0 {
+		m.textWidth -= m.digits + 3
+	}
+	if m.bar {
+		m.textWidth--
+	}
+	if !m.wrap || m.textWidth <= 0 {
+		return
+	}
+	for {
+		m.rows = m.rows[:0]
+		row := 0
+		for _, line := range m.lines {
+			m.rows = append(m.rows, row)
+			row += len(m.wrapLine(line))
+		}
+		m.viewport.setRows(row + more)
+		// Wrapped lines that outgrow the viewport need the scrollbar too,
+		// which leaves them one column less.
+		if m.bar || !m.overflows() {
+			return
+		}
+		m.bar = true
+		m.textWidth--
+	}
+}
+
+// overflows reports whether the result is taller than the viewport, and
+// so has a scrollbar if enabled.
+func (m model) overflows() bool {
+	return m.scrollbar && m.viewport.rows > m.viewport.Height
+}
+
+// loadMore shows more lines of a result cut by --max-lines.
+func (m *model) loadMore() {
+	if m.hiddenLines == 0 {
+		return
+	}
+	m.lineLimit += m.maxLines
+	m.refreshContent()
+}
+
+// showLine makes sure line is not cut off by --max-lines.
+func (m *model) showLine(line int) {
+	if line >= len(m.lines) && m.hiddenLines > 0 {
+		m.lineLimit = (line/m.maxLines + 1) * m.maxLines
+		m.refreshContent()
+	}
+}
+
+// wrapLine returns the rows a line takes with wrapping on.
+func (m model) wrapLine(line string) []string {
+	if ansi.StringWidth(line) <= m.textWidth {
+		return []string{line}
+	}
+	return strings.Split(ansi.Hardwrap(line, m.textWidth, true), "\n")
+}
+
+// visibleRows renders the rows in view: it highlights search matches and
+// the mouse selection, wraps long lines or, with wrapping off, cuts them
+// to the columns scrolled into view, and adds the line number gutter if
+// enabled.
+func (m model) visibleRows() []string {
+	var rows []string
+	top := m.viewport.YOffset
+	i := m.lineAt(top)
+	skip := top - m.rowOf(i)
+	for ; i < len(m.lines) && len(rows) < m.viewport.Height; i++ {
+		line := m.lines[i]
+		if idx := m.search.lineMatches(i); len(idx) > 0 {
+			line = m.search.highlightLine(line, idx)
+		}
+		if start, end, ok := m.selection.lineSpan(i); ok {
+			line = highlightSpan(line, start, end)
+		}
+		segments := []string{line}
+		switch {
+		case m.textWidth <= 0:
+		case m.wrap:
+			segments = m.wrapLine(line)
+		default:
+			segments[0] = ansi.Truncate(ansiutil.CutLeft(line, m.xOffset), m.textWidth, "")
+		}
+		for j, seg := range segments[min(skip, len(segments)):] {
+			if m.digits > 0 {
+				num := strings.Repeat(" ", m.digits)
+				if j+skip == 0 {
+					num = fmt.Sprintf("%*d", m.digits, i+1)
+				}
+				seg = _gutter.Render(num+" │") + " " + seg
+			}
+			rows = append(rows, seg)
+			if len(rows) == m.viewport.Height {
+				break
+			}
+		}
+		skip = 0
+	}
+	if i == len(m.lines) && m.hiddenLines > 0 && len(rows) < m.viewport.Height {
+		rows = append(rows, _gutter.Render(fmt.Sprintf("… %s more lines (press %s to load more)", formatCount(m.hiddenLines), m.keys.loadMore.Help().Key)))
+	}
+	return rows
+}
+
+// resultView renders the viewport, with the scrollbar at its right.
+func (m model) resultView() string {
+	if !m.bar {
+		return m.viewport.view(m.visibleRows())
+	}
+	p := m.viewport
+	p.Width--
+	return lipgloss.JoinHorizontal(lipgloss.Top, p.view(m.visibleRows()), m.viewport.scrollbar())
+}
+
+// rowOf returns the viewport row where line starts.
+func (m model) rowOf(line int) int {
+	if line < len(m.rows) {
+		return m.rows[line]
+	}
+	return line
+}
+
+// lineAt returns the line shown in viewport row row.
+func (m model) lineAt(row int) int {
+	if len(m.rows) == 0 {
+		return max(min(row, len(m.lines)-1), 0)
+	}
+	return max(sort.Search(len(m.rows), func(i int) bool { return m.rows[i] > row })-1, 0)
+}
+
+// longestLine returns the width of the longest line, measuring the lines
+// only once after a layout.
+func (m *model) longestLine() int {
+	if m.maxLineWidth < 0 {
+		m.maxLineWidth = 0
+		for _, line := range m.lines {
+			m.maxLineWidth = max(m.maxLineWidth, ansi.StringWidth(line))
+		}
+	}
+	return m.maxLineWidth
+}
+
+// scrollColumns scrolls the result horizontally by delta columns, keeping
+// the end of the longest line in view.
+func (m *model) scrollColumns(delta int) {
+	if m.wrap {
+		return
+	}
+	m.xOffset = max(min(m.xOffset+delta, m.longestLine()-m.textWidth), 0)
+}
@@ -0,0 +1,1302 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/itchyny/gojq"
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+var _marginTop1 = lipgloss.NewStyle().MarginTop(1)
+
+// KeyMap holds the key bindings of the interface, which Vim and Remap
+// change.
+type KeyMap struct {
+	quit            key.Binding
+	quitWith        key.Binding
+	focusNextPane   key.Binding
+	eval            key.Binding
+	logResult       key.Binding
+	toggleLive      key.Binding
+	search          key.Binding
+	nextMatch       key.Binding
+	prevMatch       key.Binding
+	historyPrev     key.Binding
+	historyNext     key.Binding
+	searchHistory   key.Binding
+	acceptSuggest   key.Binding
+	toggleRaw       key.Binding
+	toggleCompact   key.Binding
+	toggleSortKeys  key.Binding
+	cycleIndent     key.Binding
+	toggleSlurp     key.Binding
+	toggleStream    key.Binding
+	wrapStream      key.Binding
+	toggleYAML      key.Binding
+	editVars        key.Binding
+	lineNumbers     key.Binding
+	toggleWrap      key.Binding
+	toggleRedact    key.Binding
+	toggleGron      key.Binding
+	showSchema      key.Binding
+	scrollLeft      key.Binding
+	scrollRight     key.Binding
+	treeView        key.Binding
+	toggleFold      key.Binding
+	expandAll       key.Binding
+	collapseAll     key.Binding
+	explorePaths    key.Binding
+	copyPath        key.Binding
+	copyResult      key.Binding
+	copyFilter      key.Binding
+	saveResult      key.Binding
+	toggleMultiline key.Binding
+	evalProgram     key.Binding
+	openEditor      key.Binding
+	viewOriginal    key.Binding
+	toggleDiff      key.Binding
+	togglePin       key.Binding
+	pushStage       key.Binding
+	popStage        key.Binding
+	drillDown       key.Binding
+	resetInput      key.Binding
+	autoScroll      key.Binding
+	reload          key.Binding
+	loadMore        key.Binding
+	newTab          key.Binding
+	prevTab         key.Binding
+	nextTab         key.Binding
+	resultBack      key.Binding
+	resultForward   key.Binding
+	prevDocument    key.Binding
+	nextDocument    key.Binding
+	toggleSplit     key.Binding
+	narrowSplit     key.Binding
+	widenSplit      key.Binding
+	saveSnippet     key.Binding
+	snippets        key.Binding
+	leaveInput      key.Binding
+	enterInput      key.Binding
+	gotoTop         key.Binding
+	gotoBottom      key.Binding
+	gotoLine        key.Binding
+	toggleElements  key.Binding
+	prevElement     key.Binding
+	nextElement     key.Binding
+	showHelp        key.Binding
+	palette         key.Binding
+	suspend         key.Binding
+	editing         editingKeyMap
+	viewport        viewport.KeyMap
+
+	// focusViewport and tree mirror the model's focus and view so that
+	// ShortHelp can offer only the bindings relevant to the focused pane.
+	focusViewport bool
+	tree          bool
+	multiline     bool
+
+	// topTwice makes gotoTop take its key twice, like gg in vim.
+	topTwice bool
+}
+
+// DefaultKeyMap returns the default key bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		quit: key.NewBinding(
+			key.WithKeys("ctrl+c", "esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+
+		quitWith: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "accept and print…"),
+		),
+		focusNextPane: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "focus next pane"),
+		),
+		eval: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "eval, again to accept"),
+		),
+		logResult: key.NewBinding(
+			key.WithKeys("alt+l"),
+			key.WithHelp("alt+l", "log result"),
+		),
+		toggleLive: key.NewBinding(
+			key.WithKeys("alt+e"),
+			key.WithHelp("alt+e", "live eval"),
+		),
+		search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		nextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		prevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		historyPrev: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "previous filter"),
+		),
+		historyNext: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next filter"),
+		),
+		searchHistory: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "search history"),
+		),
+		acceptSuggest: key.NewBinding(
+			key.WithKeys("right"),
+			key.WithHelp("→", "accept suggestion"),
+		),
+		toggleRaw: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("alt+r", "raw output"),
+		),
+		toggleSortKeys: key.NewBinding(
+			key.WithKeys("alt+S"),
+			key.WithHelp("alt+S", "sort keys"),
+		),
+		cycleIndent: key.NewBinding(
+			key.WithKeys("alt+I"),
+			key.WithHelp("alt+I", "indent 2/4/tab"),
+		),
+		toggleCompact: key.NewBinding(
+			key.WithKeys("alt+c"),
+			key.WithHelp("alt+c", "compact output"),
+		),
+		toggleYAML: key.NewBinding(
+			key.WithKeys("alt+o"),
+			key.WithHelp("alt+o", "toggle YAML output"),
+		),
+		toggleSlurp: key.NewBinding(
+			key.WithKeys("alt+a"),
+			key.WithHelp("alt+a", "slurp into array"),
+		),
+		toggleStream: key.NewBinding(
+			key.WithKeys("alt+m"),
+			key.WithHelp("alt+m", "stream events"),
+		),
+		wrapStream: key.NewBinding(
+			key.WithKeys("alt+i"),
+			key.WithHelp("alt+i", "wrap filter for --stream"),
+		),
+		editVars: key.NewBinding(
+			key.WithKeys("ctrl+v"),
+			key.WithHelp("ctrl+v", "variables"),
+		),
+		lineNumbers: key.NewBinding(
+			key.WithKeys("alt+n"),
+			key.WithHelp("alt+n", "line numbers"),
+		),
+		toggleRedact: key.NewBinding(
+			key.WithKeys("alt+h"),
+			key.WithHelp("alt+h", "hide secrets"),
+		),
+		toggleGron: key.NewBinding(
+			key.WithKeys("="),
+			key.WithHelp("=", "gron view"),
+		),
+		showSchema: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "schema"),
+		),
+		toggleWrap: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("alt+w", "wrap lines"),
+		),
+		scrollLeft: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "scroll left"),
+		),
+		scrollRight: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "scroll right"),
+		),
+		explorePaths: key.NewBinding(
+			key.WithKeys("alt+p"),
+			key.WithHelp("alt+p", "path explorer"),
+		),
+		copyPath: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy path"),
+		),
+		copyResult: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "copy result"),
+		),
+		copyFilter: key.NewBinding(
+			key.WithKeys("alt+y"),
+			key.WithHelp("alt+y", "copy filter"),
+		),
+		saveResult: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save result"),
+		),
+		toggleMultiline: key.NewBinding(
+			key.WithKeys("alt+enter"),
+			key.WithHelp("alt+enter", "multiline editor"),
+		),
+		evalProgram: key.NewBinding(
+			key.WithKeys("ctrl+j"),
+			key.WithHelp("ctrl+j", "eval, again to accept"),
+		),
+		openEditor: key.NewBinding(
+			key.WithKeys("ctrl+e", "ctrl+x"),
+			key.WithHelp("ctrl+e", "edit in $EDITOR"),
+		),
+		saveSnippet: key.NewBinding(
+			key.WithKeys("ctrl+b"),
+			key.WithHelp("ctrl+b", "save snippet"),
+		),
+		snippets: key.NewBinding(
+			key.WithKeys("alt+u"),
+			key.WithHelp("alt+u", "snippets"),
+		),
+		viewOriginal: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "view input"),
+		),
+		toggleDiff: key.NewBinding(
+			key.WithKeys("alt+v"),
+			key.WithHelp("alt+v", "diff with previous"),
+		),
+		pushStage: key.NewBinding(
+			key.WithKeys("alt+."),
+			key.WithHelp("alt+.", "add pipeline stage"),
+		),
+		popStage: key.NewBinding(
+			key.WithKeys("alt+,"),
+			key.WithHelp("alt+,", "back to previous stage"),
+		),
+		drillDown: key.NewBinding(
+			key.WithKeys("alt+g"),
+			key.WithHelp("alt+g", "use result as input"),
+		),
+		resetInput: key.NewBinding(
+			key.WithKeys("alt+z"),
+			key.WithHelp("alt+z", "reset input"),
+		),
+		loadMore: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "load more lines"),
+		),
+		reload: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "reload input"),
+		),
+		autoScroll: key.NewBinding(
+			key.WithKeys("alt+j"),
+			key.WithHelp("alt+j", "auto-scroll"),
+		),
+		newTab: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "new tab"),
+		),
+		prevTab: key.NewBinding(
+			key.WithKeys("ctrl+left"),
+			key.WithHelp("ctrl+←", "previous tab"),
+		),
+		nextTab: key.NewBinding(
+			key.WithKeys("ctrl+right"),
+			key.WithHelp("ctrl+→", "next tab"),
+		),
+		resultBack: key.NewBinding(
+			key.WithKeys("alt+left"),
+			key.WithHelp("alt+←", "previous result"),
+		),
+		resultForward: key.NewBinding(
+			key.WithKeys("alt+right"),
+			key.WithHelp("alt+→", "next result"),
+		),
+		prevDocument: key.NewBinding(
+			key.WithKeys("ctrl+up"),
+			key.WithHelp("ctrl+↑", "previous document"),
+		),
+		nextDocument: key.NewBinding(
+			key.WithKeys("ctrl+down"),
+			key.WithHelp("ctrl+↓", "next document"),
+		),
+		togglePin: key.NewBinding(
+			key.WithKeys("alt+k"),
+			key.WithHelp("alt+k", "pin to compare"),
+		),
+		toggleSplit: key.NewBinding(
+			key.WithKeys("alt+s"),
+			key.WithHelp("alt+s", "split view"),
+		),
+		gotoTop: key.NewBinding(
+			key.WithKeys("g", "home"),
+			key.WithHelp("g", "top"),
+		),
+		gotoBottom: key.NewBinding(
+			key.WithKeys("G", "end"),
+			key.WithHelp("G", "bottom"),
+		),
+		gotoLine: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "go to line"),
+		),
+		toggleElements: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "one element at a time"),
+		),
+		prevElement: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous element"),
+		),
+		nextElement: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next element"),
+		),
+		palette: key.NewBinding(
+			key.WithKeys("ctrl+k", "alt+x"),
+			key.WithHelp("ctrl+k", "command palette"),
+		),
+		suspend: key.NewBinding(
+			key.WithKeys("ctrl+z"),
+			key.WithHelp("ctrl+z", "suspend"),
+		),
+		showHelp: key.NewBinding(
+			key.WithKeys("?", "f1"),
+			key.WithHelp("?", "all keys"),
+		),
+		narrowSplit: key.NewBinding(
+			key.WithKeys("alt+<"),
+			key.WithHelp("alt+<", "narrow left pane"),
+		),
+		widenSplit: key.NewBinding(
+			key.WithKeys("alt+>"),
+			key.WithHelp("alt+>", "widen left pane"),
+		),
+		treeView: key.NewBinding(
+			key.WithKeys("alt+t"),
+			key.WithHelp("alt+t", "tree view"),
+		),
+		toggleFold: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "fold"),
+		),
+		expandAll: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "expand all"),
+		),
+		collapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse all"),
+		),
+		editing:  defaultEditingKeyMap(),
+		viewport: viewport.DefaultKeyMap(),
+	}
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	if k.focusViewport && k.tree {
+		return []key.Binding{k.quit, k.focusNextPane, k.toggleFold, k.expandAll, k.collapseAll, k.copyPath, k.search, k.treeView}
+	}
+	if k.focusViewport {
+		return []key.Binding{k.quit, k.enterInput, k.showHelp, k.focusNextPane, k.search, k.nextMatch, k.prevMatch, k.viewport.Down, k.viewport.Up, k.viewport.PageDown, k.viewport.PageUp}
+	}
+	if k.multiline {
+		return []key.Binding{k.quit, k.leaveInput, k.evalProgram, k.toggleMultiline, k.focusNextPane, k.toggleLive, k.logResult}
+	}
+	return []key.Binding{k.quit, k.leaveInput, k.eval, k.focusNextPane, k.searchHistory, k.toggleLive, k.logResult}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	var groups [][]key.Binding
+	for _, g := range k.helpGroups() {
+		groups = append(groups, g.bindings)
+	}
+	return groups
+}
+
+// Options configure Run. The embedded engine options are jq's flags, such
+// as Raw and Slurp.
+type Options struct {
+	engine.Options
+	// Input is the text filters run on, the first document if there are
+	// several.
+	Input string
+	// InputRecords is the number of JSON values in Input, if the caller
+	// counted them already, so that a large input is not read again to
+	// count them; ijq counts them if it is 0.
+	InputRecords int
+	Filter       string
+	OutputMode   string
+	OutputYAML   bool
+	Watcher      *Watcher
+	Follow       *Follower
+	Documents    []Document
+	Engine       engine.Engine
+	EngineName   string
+	JQPath       string
+	History      *History
+	Term         io.Writer
+	// NoMouse leaves the mouse to the terminal, like --no-mouse.
+	NoMouse     bool
+	LogResults  string
+	Live        bool
+	LineNumbers bool
+	Wrap        bool
+	// NoScrollbar hides the scrollbar, like --no-scrollbar.
+	NoScrollbar bool
+	ScrollMode  string
+	Redact      bool
+	RedactKeys  *regexp.Regexp
+	SplitRatio  float64
+	YOffset     int
+	// MaxLines is the number of lines of the result shown at first, and
+	// 0 shows them all; the CLI's default is DefaultMaxLines.
+	MaxLines int
+	Timeout  time.Duration
+	// Debounce is how long --live waits after a keystroke, and 0
+	// evaluates on every one; the CLI's default is DefaultDebounce.
+	Debounce time.Duration
+	Keys     *KeyMap
+	// Notice is shown in the status bar on startup.
+	Notice string
+}
+
+type model struct {
+	help       help.Model
+	content    string
+	result     string
+	status     string
+	spinner    spinner.Model
+	viewport   pager
+	keys       KeyMap
+	textinput  textinput.Model
+	editor     textarea.Model
+	multiline  bool
+	outputMode string
+	errText    string
+	outputYAML bool
+	yamlResult string
+	gron       bool
+	gronResult string
+	// gronPaths holds the path of each line of gronResult.
+	gronPaths    [][]any
+	elements     elementView
+	resultFilter string
+	resultBytes  int
+	resultLines  int
+	evalTime     time.Duration
+	cached       bool
+	evaluated    string
+	exitCode     int
+	accepted     bool
+	history      *History
+	snippets     []snippet
+	overlay      overlay
+	search       search
+	lineJump     lineJump
+	selection    selection
+	completer    completer
+	keyIndex     keyIndex
+	watcher      *Watcher
+	follow       *Follower
+	// followEval is set while the filter runs over newly followed records,
+	// and followQueue holds those read meanwhile.
+	followEval  bool
+	followQueue string
+	autoScroll  bool
+	// records is the number of JSON values in the input, shown if there
+	// are several, as in JSON Lines.
+	records   int
+	syntaxErr *gojq.ParseError
+	resultLog *resultLog
+	// logEval is the ID of the evaluation whose result is to be logged,
+	// or 0. A later evaluation supersedes it, and nothing is logged.
+	logEval int
+	engine  engine.Engine
+	// engineName and jqPath are the engine and jq binary asked for with
+	// --engine and --jq-path, to switch back to from gojq.
+	engineName  string
+	jqPath      string
+	term        io.Writer
+	mouse       bool
+	evalOptions engine.Options
+	cancelEval  context.CancelFunc
+	cache       *resultCache
+	// stream receives the output of the running evaluation, of which
+	// streamShown bytes are on screen.
+	stream        *engine.OutputBuffer
+	streamShown   int
+	evalStart     time.Time
+	debounce      time.Duration
+	timeout       time.Duration
+	evalID        int
+	width         int
+	height        int
+	ready         bool
+	focusViewport bool
+	focusSource   bool
+	pendingTop    bool
+	split         bool
+	splitRatio    float64
+	pinned        *pin
+	tabs          []tab
+	results       resultHistory
+	documents     []input.Document
+	activeDoc     int
+	stages        []stage
+	rootContent   string
+	rootRecords   int
+	activeTab     int
+	source        viewport.Model
+	live          bool
+	lineNumbers   bool
+	redact        bool
+	redactKeys    *regexp.Regexp
+	wrap          bool
+	scrollbar     bool
+	scrollMode    string
+	xOffset       int
+	original      string
+	prevResult    string
+	diff          bool
+	diffText      string
+	showOriginal  bool
+	yOffset       int
+	tree          *tree
+
+	// benchmarking is set while a benchmark runs, and lastBench sums up
+	// the last one that finished.
+	benchmarking bool
+	lastBench    *benchStats
+
+	// maxLines is how many more lines of the result m shows, and
+	// lineLimit how many are shown.
+	maxLines  int
+	lineLimit int
+
+	// lines, rows, hiddenLines, digits, textWidth and maxLineWidth describe
+	// the result as laid out in the viewport by the last refreshContent,
+	// and bar whether it has a scrollbar.
+	lines        []string
+	rows         []int
+	hiddenLines  int
+	digits       int
+	textWidth    int
+	maxLineWidth int
+	bar          bool
+}
+
+func newModel(content string, opts Options) model {
+	ti := textinput.New()
+	ti.Focus()
+	ti.Placeholder = "jq filter"
+	ti.PromptStyle = _prompt
+	ti.SetValue(opts.Filter)
+
+	keys := *opts.Keys
+	ti.ShowSuggestions = true
+	ti.KeyMap.AcceptSuggestion = keys.acceptSuggest
+	editor := newEditor()
+	editor.FocusedStyle.Prompt = _prompt
+	keys.editing.apply(&ti.KeyMap, &editor.KeyMap)
+	var rl *resultLog
+	if opts.LogResults != "" {
+		rl = &resultLog{path: opts.LogResults}
+	} else {
+		keys.logResult.SetEnabled(false)
+	}
+	keys.autoScroll.SetEnabled(opts.Follow != nil)
+	keys.reload.SetEnabled(opts.Watcher != nil)
+	keys.prevDocument.SetEnabled(len(opts.Documents) > 1)
+	keys.nextDocument.SetEnabled(len(opts.Documents) > 1)
+
+	records := opts.InputRecords
+	if records == 0 {
+		records = input.CountRecords(content)
+	}
+	m := model{
+		content:     content,
+		rootContent: content,
+		records:     records,
+		rootRecords: records,
+		watcher:     opts.Watcher,
+		documents:   opts.Documents,
+		follow:      opts.Follow,
+		autoScroll:  opts.Follow != nil,
+		keys:        keys,
+		textinput:   ti,
+		editor:      editor,
+		outputMode:  opts.OutputMode,
+		outputYAML:  opts.OutputYAML,
+		history:     cmp.Or(opts.History, &History{}),
+		help:        newHelp(),
+		spinner:     spinner.New(spinner.WithSpinner(spinner.Dot)),
+		search:      newSearch(),
+		lineJump:    newLineJump(),
+		completer:   newCompleter(engine.LibraryFuncs()),
+		resultLog:   rl,
+		engine:      opts.Engine,
+		engineName:  opts.EngineName,
+		jqPath:      opts.JQPath,
+		status:      cmp.Or(opts.Notice, opts.Engine.Describe()),
+		cache:       newResultCache(),
+		term:        opts.Term,
+		mouse:       !opts.NoMouse,
+		evalOptions: opts.Options,
+		debounce:    opts.Debounce,
+		timeout:     opts.Timeout,
+		live:        opts.Live,
+		lineNumbers: opts.LineNumbers,
+		redact:      opts.Redact,
+		redactKeys:  opts.RedactKeys,
+		wrap:        opts.Wrap,
+		scrollbar:   !opts.NoScrollbar,
+		scrollMode:  opts.ScrollMode,
+		splitRatio:  opts.SplitRatio,
+		yOffset:     opts.YOffset,
+		maxLines:    opts.MaxLines,
+		lineLimit:   opts.MaxLines,
+	}
+	m.search.monochrome = opts.Monochrome
+	// A program read with -f may span several lines.
+	if strings.Contains(opts.Filter, "\n") {
+		m.multiline, m.keys.multiline = true, true
+		m.setFilterValue(opts.Filter)
+		m.focusInput()
+	}
+	m.validateFilter()
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	id := m.evalID
+	cmd := func() tea.Msg {
+		return debounceMsg{id: id}
+	}
+	switch {
+	case m.watcher != nil:
+		return tea.Batch(cmd, m.watcher.tick())
+	case m.follow != nil:
+		return tea.Batch(cmd, m.follow.wait())
+	}
+	return cmd
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if !m.ready {
+			m.viewport = newPager(msg.Width, 0)
+			m.viewport.KeyMap = m.keys.viewport
+			m.refreshContent()
+			m.ready = true
+		}
+		m.textinput.Width = msg.Width
+		m.editor.SetWidth(msg.Width)
+		m.help.Width = msg.Width
+		m.resize()
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.suspend) {
+			return m, m.suspend()
+		}
+		if m.overlay != nil {
+			cmd = m.updateOverlay(msg)
+			break
+		}
+		if m.search.prompting {
+			cmd = m.updateSearch(msg)
+			break
+		}
+		if m.lineJump.prompting {
+			cmd = m.updateLineJump(msg)
+			break
+		}
+		if msg.Paste {
+			cmd = m.paste(string(msg.Runes))
+			break
+		}
+		if m.completer.visible() {
+			if ok, c := m.updateCompleter(msg); ok {
+				cmd = c
+				break
+			}
+		}
+		switch {
+		case key.Matches(msg, m.keys.quit):
+			return m, m.quit(false)
+		case key.Matches(msg, m.keys.quitWith):
+			m.openOverlay(newPicker(pickOutputMode, "quit and print", OutputModes))
+		case key.Matches(msg, m.keys.focusNextPane):
+			cmd = m.cycleFocus()
+		case key.Matches(msg, m.keys.leaveInput) && !m.focusViewport:
+			m.focusResult()
+		// Keys that type a character only open the help outside the filter.
+		case key.Matches(msg, m.keys.showHelp) && (m.focusViewport || msg.Type != tea.KeyRunes):
+			// eval is only disabled to hide it while the result has focus.
+			keys := m.keys
+			keys.eval.SetEnabled(true)
+			m.openOverlay(newHelpOverlay(keys.helpGroups(), keys.viewport))
+		case key.Matches(msg, m.keys.palette):
+			m.openOverlay(newPicker(pickCommand, "command palette", m.paletteLabels()))
+		case key.Matches(msg, m.keys.eval, m.keys.evalProgram):
+			switch {
+			case m.focusViewport:
+				cmd = m.updateViewport(msg)
+			case m.multiline && !key.Matches(msg, m.keys.evalProgram):
+				cmd = m.updateInput(msg)
+			case m.upToDate():
+				return m, m.quit(true)
+			default:
+				if err := m.history.add(m.jqFilter()); err != nil {
+					m.setStatus(err, "")
+				}
+				cmd = m.startEval()
+			}
+		case key.Matches(msg, m.keys.saveSnippet):
+			m.openOverlay(newSnippetPrompt(m.jqFilter()))
+		case key.Matches(msg, m.keys.snippets):
+			m.openSnippets()
+		case key.Matches(msg, m.keys.openEditor):
+			cmd = m.openEditor()
+		case key.Matches(msg, m.keys.toggleMultiline):
+			cmd = m.toggleMultiline()
+		case key.Matches(msg, m.keys.historyPrev, m.keys.historyNext):
+			if m.focusViewport {
+				cmd = m.updateViewport(msg)
+				break
+			}
+			if m.multiline {
+				cmd = m.updateInput(msg)
+				break
+			}
+			var (
+				filter string
+				ok     bool
+			)
+			if key.Matches(msg, m.keys.historyPrev) {
+				filter, ok = m.history.prev(m.filterValue())
+			} else {
+				filter, ok = m.history.next()
+			}
+			if ok {
+				cmd = m.setFilter(filter)
+			}
+		case key.Matches(msg, m.keys.searchHistory):
+			if !m.focusViewport {
+				m.openOverlay(newPicker(pickHistory, "history search", m.history.recent()))
+			}
+		case key.Matches(msg, m.keys.toggleRaw):
+			m.evalOptions.Raw = !m.evalOptions.Raw
+			m.setStatus(nil, "raw output %s", onOff(m.evalOptions.Raw))
+			cmd = m.startEval()
+		case key.Matches(msg, m.keys.toggleCompact):
+			m.evalOptions.Compact = !m.evalOptions.Compact
+			m.setStatus(nil, "compact output %s", onOff(m.evalOptions.Compact))
+			cmd = m.startEval()
+		case key.Matches(msg, m.keys.toggleSortKeys):
+			m.evalOptions.SortKeys = !m.evalOptions.SortKeys
+			m.setStatus(nil, "sort keys %s", onOff(m.evalOptions.SortKeys))
+			cmd = m.startEval()
+		case key.Matches(msg, m.keys.cycleIndent):
+			m.cycleIndent()
+			cmd = m.startEval()
+		case key.Matches(msg, m.keys.toggleSlurp):
+			m.evalOptions.Slurp = !m.evalOptions.Slurp
+			m.setStatus(nil, "slurp %s", onOff(m.evalOptions.Slurp))
+			cmd = m.startEval()
+		case key.Matches(msg, m.keys.toggleStream):
+			cmd = m.toggleStream()
+		case key.Matches(msg, m.keys.wrapStream):
+			cmd = m.wrapStream()
+		case key.Matches(msg, m.keys.toggleYAML):
+			m.toggleYAML()
+		case key.Matches(msg, m.keys.editVars):
+			m.openOverlay(newVarsPanel(m.evalOptions.Vars))
+		case key.Matches(msg, m.keys.toggleLive):
+			m.live = !m.live
+			m.setStatus(nil, "live eval %s", onOff(m.live))
+			if m.live {
+				cmd = m.scheduleEval()
+			}
+		case key.Matches(msg, m.keys.lineNumbers):
+			m.lineNumbers = !m.lineNumbers
+			m.setStatus(nil, "line numbers %s", onOff(m.lineNumbers))
+			m.refreshContent()
+		case key.Matches(msg, m.keys.toggleRedact):
+			m.toggleRedact()
+		case key.Matches(msg, m.keys.toggleWrap):
+			m.wrap = !m.wrap
+			m.xOffset = 0
+			m.setStatus(nil, "wrap lines %s", onOff(m.wrap))
+			m.refreshContent()
+		case key.Matches(msg, m.keys.explorePaths):
+			if e, err := newExplorer(m.content); err != nil {
+				m.setStatus(err, "")
+			} else {
+				m.openOverlay(e)
+			}
+		case key.Matches(msg, m.keys.saveResult):
+			m.openOverlay(newSavePrompt())
+		case key.Matches(msg, m.keys.copyResult):
+			m.copy("result", m.resultText())
+		case key.Matches(msg, m.keys.copyFilter):
+			m.copy("filter", m.pipeline())
+		case key.Matches(msg, m.keys.pushStage):
+			cmd = m.pushStage()
+		case key.Matches(msg, m.keys.popStage):
+			cmd = m.popStage()
+		case key.Matches(msg, m.keys.drillDown):
+			cmd = m.drillDown()
+		case key.Matches(msg, m.keys.resetInput):
+			cmd = m.resetInput()
+		case key.Matches(msg, m.keys.newTab):
+			cmd = m.newTab()
+		case key.Matches(msg, m.keys.prevTab):
+			cmd = m.switchTab(-1)
+		case key.Matches(msg, m.keys.nextTab):
+			cmd = m.switchTab(1)
+		case key.Matches(msg, m.keys.resultBack):
+			cmd = m.stepResult(-1)
+		case key.Matches(msg, m.keys.resultForward):
+			cmd = m.stepResult(1)
+		case key.Matches(msg, m.keys.prevDocument):
+			cmd = m.switchDocument(-1)
+		case key.Matches(msg, m.keys.nextDocument):
+			cmd = m.switchDocument(1)
+		case key.Matches(msg, m.keys.togglePin):
+			m.togglePin()
+		case key.Matches(msg, m.keys.toggleDiff):
+			m.toggleDiff()
+		case key.Matches(msg, m.keys.toggleSplit):
+			m.toggleSplit()
+		case key.Matches(msg, m.keys.narrowSplit):
+			m.resizeSplit(-_splitStep)
+		case key.Matches(msg, m.keys.widenSplit):
+			m.resizeSplit(_splitStep)
+		case key.Matches(msg, m.keys.treeView):
+			m.toggleTree()
+		case bound(msg, m.keys.autoScroll):
+			if m.follow != nil {
+				m.toggleAutoScroll()
+			}
+		case bound(msg, m.keys.logResult):
+			if m.resultLog != nil {
+				if m.upToDate() {
+					m.logResult()
+				} else {
+					cmd = m.startEval()
+					m.logEval = m.evalID
+				}
+			}
+		default:
+			if !m.focusViewport {
+				cmd = m.updateInput(msg)
+			} else {
+				cmd = m.updateViewport(msg)
+			}
+		}
+
+	case tea.MouseMsg:
+		cmd = m.updateMouse(msg)
+
+	case originalMsg:
+		m.originalLoaded(msg)
+
+	case editorMsg:
+		cmd = m.editorDone(msg)
+
+	case suspendMsg:
+		cmd = m.suspend()
+
+	case resumeMsg:
+		cmd = m.resumed(msg)
+
+	case debounceMsg:
+		if msg.id == m.evalID {
+			cmd = m.startEval()
+		}
+
+	case spinner.TickMsg:
+		if m.evaluating() {
+			m.spinner, cmd = m.spinner.Update(msg)
+			m.showStream()
+		}
+
+	case watchTickMsg:
+		cmd = m.watcher.tick()
+		if m.watcher.changed() {
+			cmd = tea.Batch(cmd, m.watcher.reload())
+		}
+
+	case inputReloadedMsg:
+		cmd = m.inputReloaded(msg)
+
+	case followMsg:
+		cmd = m.followed(msg)
+
+	case followEvalMsg:
+		cmd = m.followEvaluated(msg)
+
+	case benchMsg:
+		m.benchmarked(msg)
+
+	case evalMsg:
+		if msg.id == m.evalID {
+			m.cancelEval = nil
+			m.exitCode = msg.ExitCode
+			m.evalTime = msg.duration
+			m.cached = msg.cached
+			m.evaluated = m.evalKey()
+			m.errText = msg.Errors
+			m.endStream()
+			if msg.Dropped > 0 {
+				m.setStatus(nil, "output truncated after %s", input.FormatBytes(engine.MaxOutput))
+			}
+			// A failing filter, such as a partially typed one, leaves the
+			// last good result in place.
+			if msg.ExitCode == 0 {
+				anchor := m.scrollAnchor()
+				if msg.Output != m.result {
+					m.prevResult = m.result
+				}
+				m.result = msg.Output
+				m.updateYAML()
+				m.updateGron()
+				m.updateElements()
+				m.resultFilter = msg.filter
+				m.resultBytes = len(ansi.Strip(msg.Output))
+				m.resultLines = strings.Count(msg.Output, "\n")
+				m.updateDiff()
+				if !m.showOriginal {
+					m.resetView()
+					m.restoreScroll(m.scrollMode, anchor)
+				}
+				// A restored session scrolls back to where it left off
+				// once its result is in.
+				if m.yOffset > 0 {
+					m.viewport.SetYOffset(m.yOffset)
+					m.yOffset = 0
+				} else if m.autoScroll {
+					m.viewport.GotoBottom()
+				}
+				m.recordResult()
+			}
+			if msg.id == m.logEval {
+				m.logResult()
+			}
+			m.resize()
+		}
+
+	default:
+	}
+
+	return m, cmd
+}
+
+func (m model) View() string {
+	if h, ok := m.overlay.(*helpOverlay); ok {
+		footer := m.footerView()
+		return h.view(m.width, max(m.height-lipgloss.Height(footer), 0)) + "\n" + footer
+	}
+	var sb strings.Builder
+	if header := m.headerView(); header != "" {
+		sb.WriteString(header)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(m.inputView())
+	sb.WriteByte('\n')
+	if syntax := m.syntaxView(); syntax != "" {
+		sb.WriteString(syntax)
+		sb.WriteByte('\n')
+	}
+	if errs := errorView(m.errText, m.width); errs != "" {
+		sb.WriteString(errs)
+		sb.WriteByte('\n')
+	}
+	if m.overlay != nil {
+		sb.WriteString(m.overlay.view(m.width, m.viewport.Height))
+	} else if m.split {
+		sb.WriteString(m.splitView())
+	} else if m.completer.visible() {
+		sb.WriteString(m.completionsView(m.resultView()))
+	} else {
+		sb.WriteString(m.resultView())
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(m.footerView())
+	return sb.String()
+}
+
+func (m model) footerView() string {
+	var footer string
+	switch {
+	case m.overlay != nil:
+		footer = m.help.View(m.overlay.keyMap())
+	case m.search.prompting:
+		footer = m.help.View(m.search.keyMap())
+	case m.lineJump.prompting:
+		footer = m.help.View(m.lineJump.keyMap())
+	case m.completer.visible():
+		footer = m.help.View(m.completer.keys)
+	default:
+		footer = m.help.View(m.keys)
+	}
+	status := m.statusView()
+	if m.search.prompting {
+		status = m.search.promptView(m.width)
+	} else if m.lineJump.prompting {
+		status = m.lineJump.promptView(m.width, len(m.lines)+m.hiddenLines)
+	}
+	return status + "\n" + _marginTop1.Render(footer)
+}
+
+// resize recomputes the viewport height from the space left over by the
+// input line and footer.
+func (m *model) resize() {
+	if !m.ready {
+		return
+	}
+	margin := lipgloss.Height(m.inputView()) + lipgloss.Height(m.footerView())
+	if m.syntaxErr != nil {
+		margin++
+	}
+	if header := m.headerView(); header != "" {
+		margin += lipgloss.Height(header)
+	}
+	if errs := errorView(m.errText, m.width); errs != "" {
+		margin += lipgloss.Height(errs)
+	}
+	m.viewport.Height = max(m.height-margin, 0)
+	// The scrollbar comes and goes as the result outgrows the height.
+	if w := m.resultWidth(); m.viewport.Width != w || m.bar != m.overflows() {
+		m.viewport.Width = w
+		m.refreshContent()
+	}
+	m.resizeSource()
+}
+
+func (m *model) openOverlay(o overlay) {
+	m.overlay = o
+	m.resize()
+}
+
+// updateOverlay forwards a key press to the open overlay, closing it once
+// it is done.
+func (m *model) updateOverlay(msg tea.KeyMsg) tea.Cmd {
+	done, cmd := m.overlay.update(m, msg)
+	if done {
+		m.overlay = nil
+		m.resize()
+	}
+	return cmd
+}
+
+// updateViewport handles a key press while the result viewport has focus.
+func (m *model) updateViewport(msg tea.KeyMsg) tea.Cmd {
+	if key.Matches(msg, m.keys.enterInput) {
+		return m.focusFilter()
+	}
+	first := m.keys.topTwice && key.Matches(msg, m.keys.gotoTop) && !m.pendingTop
+	m.pendingTop = first
+	if first {
+		return nil
+	}
+	if m.focusSource {
+		var cmd tea.Cmd
+		m.source, cmd = m.source.Update(msg)
+		return cmd
+	}
+	if m.tree != nil && m.updateTree(msg) {
+		return nil
+	}
+	switch {
+	case key.Matches(msg, m.keys.search):
+		return m.openSearch()
+	case key.Matches(msg, m.keys.nextMatch):
+		m.nextMatch(1)
+	case key.Matches(msg, m.keys.prevMatch):
+		m.nextMatch(-1)
+	case key.Matches(msg, m.keys.copyPath):
+		m.copyPath()
+	case key.Matches(msg, m.keys.viewOriginal):
+		return m.toggleOriginal()
+	case key.Matches(msg, m.keys.loadMore):
+		m.loadMore()
+	case key.Matches(msg, m.keys.toggleGron):
+		m.toggleGron()
+	case key.Matches(msg, m.keys.showSchema):
+		if o, err := newSchemaOverlay(m.result, m.keys.showSchema.Keys(), m.keys.viewport); err != nil {
+			m.setStatus(err, "")
+		} else {
+			m.openOverlay(o)
+		}
+	case key.Matches(msg, m.keys.gotoTop):
+		m.viewport.GotoTop()
+	case key.Matches(msg, m.keys.gotoBottom):
+		m.viewport.GotoBottom()
+	case key.Matches(msg, m.keys.gotoLine):
+		return m.openLineJump()
+	case key.Matches(msg, m.keys.toggleElements):
+		m.toggleElements()
+	case key.Matches(msg, m.keys.prevElement):
+		m.stepElement(-1)
+	case key.Matches(msg, m.keys.nextElement):
+		m.stepElement(1)
+	case bound(msg, m.keys.reload):
+		if m.watcher != nil {
+			m.setStatus(nil, "reloading input…")
+			return m.watcher.reload()
+		}
+	case key.Matches(msg, m.keys.scrollLeft):
+		m.scrollColumns(-_scrollStep)
+	case key.Matches(msg, m.keys.scrollRight):
+		m.scrollColumns(_scrollStep)
+	default:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// shownText returns the result, or the input document or the diff against
+// the previous result while one is shown in its place, with secrets masked
+// while redaction is on.
+func (m model) shownText() string {
+	switch {
+	case m.showOriginal:
+		return m.redacted(m.original)
+	case m.diff:
+		return m.redacted(m.diffText)
+	}
+	if e, ok := m.elements.element(); ok {
+		return e
+	}
+	switch {
+	case m.gron:
+		return m.gronResult
+	case m.outputYAML:
+		return m.yamlResult
+	}
+	return m.redacted(m.result)
+}
+
+// viewText returns the text shown in the viewport: the shown text, or the
+// visible part of its tree, ready to draw.
+func (m model) viewText() string {
+	if m.tree != nil {
+		return m.drawable(m.tree.render())
+	}
+	return m.drawable(m.shownText())
+}
+
+// drawable makes text safe to draw: any control characters in it are
+// replaced, and its colors removed on a monochrome terminal.
+func (m model) drawable(text string) string {
+	if m.evalOptions.Monochrome {
+		text = ansi.Strip(text)
+	}
+	return ansiutil.Sanitize(text)
+}
+
+// resetView redraws the viewport from the top after the shown text
+// changed, keeping the folds of the tree view.
+func (m *model) resetView() {
+	m.xOffset = 0
+	m.lineLimit = m.maxLines
+	m.updateView()
+	m.viewport.GotoTop()
+}
+
+// updateView redraws the viewport after the shown text changed, keeping
+// the folds of the tree view and the scroll position.
+func (m *model) updateView() {
+	if m.tree != nil {
+		var err error
+		if m.tree, err = newTree(m.shownText(), m.tree); err != nil {
+			m.keys.tree = false
+			m.setStatus(err, "")
+		}
+	}
+	m.search.find(m.viewText())
+	m.refreshContent()
+}
+
+// refreshContent redraws the result in the viewport.
+func (m *model) refreshContent() {
+	m.layout(m.viewText())
+}
+
+// quit stops any evaluation in flight and exits the program. Only an
+// accepted filter is printed on exit.
+func (m *model) quit(accept bool) tea.Cmd {
+	m.stopEval()
+	m.accepted = accept
+	if accept {
+		_ = m.history.add(m.pipeline())
+	}
+	return tea.Quit
+}
+
+// upToDate reports whether the shown result belongs to the current filter
+// and options, so that pressing enter again accepts it.
+func (m model) upToDate() bool {
+	return !m.evaluating() && m.evaluated == m.evalKey()
+}
+
+// evalKey identifies the filter and options an evaluation runs with.
+func (m model) evalKey() string {
+	return strings.Join(append(m.evalOptions.Flags(), m.jqFilter()), "\x00")
+}
+
+// pick acts on an item chosen in a picker.
+func (m *model) pick(kind pickerKind, choice string) tea.Cmd {
+	switch kind {
+	case pickHistory:
+		return m.setFilter(choice)
+	case pickOutputMode:
+		m.outputMode = choice
+		return m.quit(true)
+	case pickSnippet:
+		return m.insertFilter(m.pickedSnippet(choice))
+	case pickCommand:
+		return m.runAction(choice)
+	}
+	return nil
+}
+
+// setFilter replaces the filter input, re-evaluating it in live mode.
+func (m *model) setFilter(filter string) tea.Cmd {
+	m.setFilterValue(filter)
+	if m.live {
+		return m.scheduleEval()
+	}
+	return nil
+}
+
+func (m model) jqFilter() string {
+	return cmp.Or(strings.TrimSpace(m.filterValue()), ".")
+}
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// SGR sequences that show the text selected with the mouse in reverse
+// video.
+const (
+	_selectOn  = "\x1b[7m"
+	_selectOff = "\x1b[27m"
+)
+
+// textPos is a byte offset into the uncolored text of a result line.
+type textPos struct {
+	line, col int
+}
+
+func (p textPos) before(q textPos) bool {
+	return p.line < q.line || p.line == q.line && p.col < q.col
+}
+
+// selection is the result text selected by dragging the mouse, from where
+// the button went down to where it is now or was let go.
+type selection struct {
+	dragging     bool
+	anchor, head textPos
+}
+
+func (s selection) empty() bool {
+	return s.anchor == s.head
+}
+
+// span returns the ends of the selection in order.
+func (s selection) span() (from, to textPos) {
+	if s.head.before(s.anchor) {
+		return s.head, s.anchor
+	}
+	return s.anchor, s.head
+}
+
+// lineSpan returns the byte range of line within the selection.
+func (s selection) lineSpan(line int) (start, end int, ok bool) {
+	from, to := s.span()
+	if s.empty() || line < from.line || line > to.line {
+		return 0, 0, false
+	}
+	end = -1
+	if line == from.line {
+		start = from.col
+	}
+	if line == to.line {
+		end = to.col
+	}
+	return start, end, true
+}
+
+// highlightSpan shows the bytes [start, end) of the uncolored text of
+// line in reverse video, or up to the end of the line if end is -1.
+func highlightSpan(line string, start, end int) string {
+	var sb strings.Builder
+	pos, in := 0, false
+	for i := 0; i < len(line); {
+		if n := ansiutil.EscapeLen(line[i:]); n > 0 {
+			sb.WriteString(line[i : i+n])
+			// A reset inside the selection turns it off; restore it.
+			if in {
+				sb.WriteString(_selectOn)
+			}
+			i += n
+			continue
+		}
+		if in && pos == end {
+			sb.WriteString(_selectOff)
+			in = false
+		}
+		if !in && pos == start && pos != end {
+			sb.WriteString(_selectOn)
+			in = true
+		}
+		sb.WriteByte(line[i])
+		pos++
+		i++
+	}
+	if in {
+		sb.WriteString(_selectOff)
+	}
+	return sb.String()
+}
+
+// byteAt returns the offset of the character of s at column col.
+func byteAt(s string, col int) int {
+	w := 0
+	for i, r := range s {
+		w += ansi.StringWidth(string(r))
+		if w > col {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// resultTop returns the screen row where the result starts.
+func (m model) resultTop() int {
+	top := lipgloss.Height(m.inputView())
+	if header := m.headerView(); header != "" {
+		top += lipgloss.Height(header)
+	}
+	if syntax := m.syntaxView(); syntax != "" {
+		top += lipgloss.Height(syntax)
+	}
+	if errs := errorView(m.errText, m.width); errs != "" {
+		top += lipgloss.Height(errs)
+	}
+	return top
+}
+
+// textPosAt returns the place in the result shown at column x and row y of
+// the viewport, which may be outside it while dragging.
+func (m model) textPosAt(x, y int) textPos {
+	if len(m.lines) == 0 {
+		return textPos{}
+	}
+	row := m.viewport.YOffset + min(max(y, 0), m.viewport.Height-1)
+	line := m.lineAt(row)
+	plain := ansi.Strip(m.lines[line])
+	end := textPos{line, len(plain)}
+	col := x
+	if m.digits > 0 {
+		col -= m.digits + 3
+	}
+	switch {
+	case m.wrap && m.textWidth > 0:
+		seg := row - m.rowOf(line)
+		if seg >= len(m.wrapLine(m.lines[line])) {
+			return end
+		}
+		col += seg * m.textWidth
+	case row >= len(m.lines):
+		return end
+	default:
+		col += m.xOffset
+	}
+	return textPos{line, byteAt(plain, max(col, 0))}
+}
+
+// selectedText returns the uncolored text of the selection.
+func (m model) selectedText() string {
+	from, to := m.selection.span()
+	var sb strings.Builder
+	for i := from.line; i <= to.line && i < len(m.lines); i++ {
+		plain := ansi.Strip(m.lines[i])
+		start, end := 0, len(plain)
+		if i == from.line {
+			start = min(from.col, end)
+		}
+		if i == to.line {
+			end = max(min(to.col, end), start)
+		}
+		sb.WriteString(plain[start:end])
+		if i < to.line {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// updateMouse scrolls the pane under the wheel, focuses the pane clicked,
+// and selects result text by dragging, copying it when the button is let
+// go.
+func (m *model) updateMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.overlay != nil || m.search.prompting || m.lineJump.prompting {
+		return nil
+	}
+	top := m.resultTop()
+	left := m.width - m.resultWidth()
+	inPanes := msg.Y >= top && msg.Y < top+m.viewport.Height
+	inSource := inPanes && m.split && msg.X < m.source.Width
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown:
+		if inSource {
+			var cmd tea.Cmd
+			m.source, cmd = m.source.Update(msg)
+			return cmd
+		}
+		m.viewport, _ = m.viewport.Update(msg)
+
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		m.selection = selection{}
+		switch {
+		case msg.Y < top:
+			if m.focusViewport {
+				return m.focusFilter()
+			}
+		case inSource:
+			if !m.focusViewport {
+				m.focusResult()
+			}
+			m.focusSource = true
+		case inPanes && msg.X >= left:
+			if !m.focusViewport {
+				m.focusResult()
+			}
+			m.focusSource = false
+			pos := m.textPosAt(msg.X-left, msg.Y-top)
+			if m.tree != nil && len(m.lines) > 0 {
+				m.tree.cursor = pos.line
+				m.refreshContent()
+			}
+			m.selection = selection{dragging: true, anchor: pos, head: pos}
+		}
+
+	case msg.Action == tea.MouseActionMotion && m.selection.dragging:
+		// Dragging past the top or bottom scrolls the result.
+		switch {
+		case msg.Y < top:
+			m.viewport.SetYOffset(m.viewport.YOffset - 1)
+		case msg.Y >= top+m.viewport.Height:
+			m.viewport.SetYOffset(m.viewport.YOffset + 1)
+		}
+		m.selection.head = m.textPosAt(msg.X-left, msg.Y-top)
+
+	case msg.Action == tea.MouseActionRelease && m.selection.dragging:
+		m.selection.dragging = false
+		if !m.selection.empty() {
+			m.copy("selection", m.selectedText())
+		}
+	}
+	return nil
+}
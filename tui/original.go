@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// originalMsg carries the pretty-printed input document.
+type originalMsg struct {
+	engine.Result
+}
+
+// toggleOriginal swaps the result in the viewport for the pretty-printed
+// input document and back. The document is printed by the engine the first
+// time it is shown.
+func (m *model) toggleOriginal() tea.Cmd {
+	if m.showOriginal {
+		m.showOriginal = false
+		m.setStatus(nil, "showing result")
+		m.resetView()
+		return nil
+	}
+	if m.original != "" {
+		m.originalLoaded(originalMsg{engine.Result{Output: m.original}})
+		return nil
+	}
+	m.setStatus(nil, "loading input…")
+	eng, content := m.engine, m.content
+	return func() tea.Msg {
+		return originalMsg{eng.Eval(context.Background(), content, ".", engine.Options{})}
+	}
+}
+
+func (m *model) originalLoaded(msg originalMsg) {
+	if msg.ExitCode != 0 {
+		m.setStatus(errors.New(strings.TrimSpace(msg.Errors)), "")
+		return
+	}
+	m.original = msg.Output
+	m.showOriginal = true
+	m.setStatus(nil, "showing input, press i for the result")
+	m.resetView()
+}
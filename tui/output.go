@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// What ijq prints on exit.
+const (
+	OutputFilter = "filter"
+	OutputResult = "result"
+	OutputBoth   = "both"
+)
+
+// OutputModes are the values of --output-mode.
+var OutputModes = []string{OutputFilter, OutputResult, OutputBoth}
+
+// toYAML converts a jq result to YAML, leaving output that is not JSON,
+// such as raw strings, as it is.
+func toYAML(result string) string {
+	out, err := input.JSONToYAML(result)
+	if err != nil {
+		return result
+	}
+	return out
+}
+
+// updateYAML converts the result for YAML output.
+func (m *model) updateYAML() {
+	if !m.outputYAML {
+		m.yamlResult = ""
+		return
+	}
+	m.yamlResult = toYAML(ansi.Strip(m.redacted(m.result)))
+}
+
+// cycleIndent switches the indentation of the output between 2 spaces, 4
+// spaces and a tab.
+func (m *model) cycleIndent() {
+	switch {
+	case m.evalOptions.Tab:
+		m.evalOptions.Tab, m.evalOptions.Indent = false, engine.DefaultIndent
+	case m.evalOptions.Indent == 4:
+		m.evalOptions.Tab = true
+	default:
+		m.evalOptions.Indent = 4
+	}
+	if m.evalOptions.Tab {
+		m.setStatus(nil, "indent with a tab")
+	} else {
+		m.setStatus(nil, "indent %d spaces", m.evalOptions.Indent)
+	}
+}
+
+func (m *model) toggleYAML() {
+	m.outputYAML = !m.outputYAML
+	m.updateYAML()
+	m.setStatus(nil, "YAML output %s", onOff(m.outputYAML))
+	m.resetView()
+}
+
+// resultText returns the result as plain text in the output format.
+func (m model) resultText() string {
+	if m.outputYAML {
+		return m.yamlResult
+	}
+	return ansi.Strip(m.result)
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// pager scrolls through a number of rows without holding them, so that
+// only the rows in view of a large result are ever rendered. It scrolls
+// like viewport.Model, with the same key map.
+type pager struct {
+	Width   int
+	Height  int
+	YOffset int
+	KeyMap  viewport.KeyMap
+	rows    int
+}
+
+func newPager(width, height int) pager {
+	return pager{Width: width, Height: height, KeyMap: viewport.DefaultKeyMap()}
+}
+
+// setRows sets the number of rows to scroll through.
+func (p *pager) setRows(n int) {
+	p.rows = n
+	p.SetYOffset(p.YOffset)
+}
+
+func (p pager) maxYOffset() int {
+	return max(p.rows-p.Height, 0)
+}
+
+func (p *pager) SetYOffset(n int) {
+	p.YOffset = min(max(n, 0), p.maxYOffset())
+}
+
+func (p *pager) GotoTop() {
+	p.YOffset = 0
+}
+
+func (p *pager) GotoBottom() {
+	p.YOffset = p.maxYOffset()
+}
+
+func (p pager) ScrollPercent() float64 {
+	if p.Height >= p.rows {
+		return 1
+	}
+	return min(max(float64(p.YOffset)/float64(p.rows-p.Height), 0), 1)
+}
+
+func (p pager) Update(msg tea.Msg) (pager, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, p.KeyMap.PageDown):
+			p.SetYOffset(p.YOffset + p.Height)
+		case key.Matches(msg, p.KeyMap.PageUp):
+			p.SetYOffset(p.YOffset - p.Height)
+		case key.Matches(msg, p.KeyMap.HalfPageDown):
+			p.SetYOffset(p.YOffset + p.Height/2)
+		case key.Matches(msg, p.KeyMap.HalfPageUp):
+			p.SetYOffset(p.YOffset - p.Height/2)
+		case key.Matches(msg, p.KeyMap.Down):
+			p.SetYOffset(p.YOffset + 1)
+		case key.Matches(msg, p.KeyMap.Up):
+			p.SetYOffset(p.YOffset - 1)
+		}
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			break
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			p.SetYOffset(p.YOffset - 3)
+		case tea.MouseButtonWheelDown:
+			p.SetYOffset(p.YOffset + 3)
+		}
+	}
+	return p, nil
+}
+
+// _scrollThumb styles the part of the scrollbar that stands for the rows
+// in view, against a track in the style of the gutter.
+var _scrollThumb = lipgloss.NewStyle()
+
+// scrollbar renders a column as tall as p, with a thumb as long, and as
+// far down, as the part of the rows in view.
+func (p pager) scrollbar() string {
+	if p.Height <= 0 {
+		return ""
+	}
+	thumb := max(p.Height*p.Height/max(p.rows, 1), 1)
+	top := int(math.Round(p.ScrollPercent() * float64(p.Height-thumb)))
+	rows := make([]string, p.Height)
+	for i := range rows {
+		if i >= top && i < top+thumb {
+			rows[i] = _scrollThumb.Render("┃")
+		} else {
+			rows[i] = _gutter.Render("│")
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// view pads or cuts the rows in view to the size of the pager.
+func (p pager) view(rows []string) string {
+	return lipgloss.NewStyle().
+		Width(p.Width).
+		Height(p.Height).
+		MaxHeight(p.Height).
+		MaxWidth(p.Width).
+		Render(strings.Join(rows, "\n"))
+}
+
+// textOverlay shows lines of text, such as the schema of the result, in
+// place of the result, scrolling when they do not fit.
+type textOverlay struct {
+	keys  helpKeyMap
+	lines []string
+	pager pager
+}
+
+func newTextOverlay(lines []string, closeKeys []string, keys viewport.KeyMap) *textOverlay {
+	o := &textOverlay{
+		keys: helpKeyMap{
+			close: key.NewBinding(
+				key.WithKeys(append([]string{"esc", "q", "ctrl+c"}, closeKeys...)...),
+				key.WithHelp("esc", "close"),
+			),
+			viewport: keys,
+		},
+		lines: lines,
+		pager: newPager(0, 0),
+	}
+	o.pager.KeyMap = keys
+	o.pager.setRows(len(lines))
+	return o
+}
+
+func (o *textOverlay) keyMap() help.KeyMap {
+	return o.keys
+}
+
+func (o *textOverlay) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	if key.Matches(msg, o.keys.close) {
+		return true, nil
+	}
+	o.pager, cmd = o.pager.Update(msg)
+	return false, cmd
+}
+
+func (o *textOverlay) view(width, height int) string {
+	o.pager.Width, o.pager.Height = width, height
+	o.pager.SetYOffset(o.pager.YOffset)
+	end := min(o.pager.YOffset+height, len(o.lines))
+	rows := make([]string, 0, end-o.pager.YOffset)
+	for _, l := range o.lines[o.pager.YOffset:end] {
+		rows = append(rows, ansi.Truncate(l, width, "…"))
+	}
+	return o.pager.view(rows)
+}
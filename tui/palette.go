@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paletteAction is an entry of the command palette.
+type paletteAction struct {
+	label string
+	run   func(m *model) tea.Cmd
+}
+
+// paletteActions lists what the command palette offers: the actions of the
+// key bindings that can be used now, which run by pressing their key in
+// the pane it works in, and the actions that have no key.
+func (m *model) paletteActions() []paletteAction {
+	actions := []paletteAction{
+		{"switch engine (jq/gojq)", (*model).switchEngine},
+		{"benchmark filter", (*model).benchmark},
+	}
+	keys := m.keys
+	keys.eval.SetEnabled(true)
+	for _, g := range keys.helpGroups() {
+		if g.title == "Editing" || g.pane == paneTree && m.tree == nil {
+			continue
+		}
+		for _, b := range g.bindings {
+			if !b.Enabled() || b.Help() == m.keys.palette.Help() {
+				continue
+			}
+			actions = append(actions, paletteAction{
+				label: fmt.Sprintf("%s (%s)", b.Help().Desc, b.Help().Key),
+				run:   pressKey(g.pane, b),
+			})
+		}
+	}
+	return actions
+}
+
+// pressKey returns an action that presses a key of b after focusing the
+// pane where it works. A named key is preferred to a character, which
+// the filter would take as typing.
+func pressKey(p pane, b key.Binding) func(m *model) tea.Cmd {
+	k := b.Keys()[0]
+	for _, s := range b.Keys() {
+		if utf8.RuneCountInString(s) > 1 {
+			k = s
+			break
+		}
+	}
+	msg := keyMsg(k)
+	return func(m *model) tea.Cmd {
+		var cmd tea.Cmd
+		switch {
+		case p == paneFilter && m.focusViewport:
+			cmd = m.focusFilter()
+		case p >= paneResult && !m.focusViewport:
+			m.focusResult()
+		}
+		return tea.Batch(cmd, func() tea.Msg { return msg })
+	}
+}
+
+func (m *model) paletteLabels() []string {
+	var labels []string
+	for _, a := range m.paletteActions() {
+		labels = append(labels, a.label)
+	}
+	return labels
+}
+
+// runAction runs the palette action labeled label.
+func (m *model) runAction(label string) tea.Cmd {
+	for _, a := range m.paletteActions() {
+		if a.label == label {
+			return a.run(m)
+		}
+	}
+	return nil
+}
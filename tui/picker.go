@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	_pickerTitle    = lipgloss.NewStyle().Bold(true)
+	_pickerSelected = lipgloss.NewStyle().Reverse(true)
+	_errorText      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// overlay is a modal pane drawn in place of the result viewport. While open
+// it receives every key press.
+type overlay interface {
+	// update handles a key press and reports whether the overlay is done
+	// and should be closed.
+	update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd)
+	view(width, height int) string
+	keyMap() help.KeyMap
+}
+
+// pickerKind tells the model what to do with the item chosen in a picker.
+type pickerKind int
+
+const (
+	pickHistory pickerKind = iota
+	pickOutputMode
+	pickSnippet
+	pickCommand
+)
+
+type pickerKeyMap struct {
+	up     key.Binding
+	down   key.Binding
+	choose key.Binding
+	cancel key.Binding
+}
+
+func (k pickerKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.choose, k.cancel, k.up, k.down}
+}
+
+func (k pickerKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// picker is an overlay that fuzzy-filters a list of items as the user types.
+type picker struct {
+	kind    pickerKind
+	title   string
+	input   textinput.Model
+	keys    pickerKeyMap
+	items   []string
+	matches []string
+	cursor  int
+}
+
+func newPicker(kind pickerKind, title string, items []string) *picker {
+	ti := textinput.New()
+	ti.Prompt = "? "
+	ti.Focus()
+	p := &picker{
+		kind:  kind,
+		title: title,
+		input: ti,
+		items: items,
+		keys: pickerKeyMap{
+			up: key.NewBinding(
+				key.WithKeys("up", "ctrl+p", "ctrl+r"),
+				key.WithHelp("↑/ctrl+r", "older"),
+			),
+			down: key.NewBinding(
+				key.WithKeys("down", "ctrl+n", "ctrl+s"),
+				key.WithHelp("↓/ctrl+s", "newer"),
+			),
+			choose: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "choose"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+g", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+		},
+	}
+	if kind != pickHistory {
+		p.keys.up.SetHelp("↑", "up")
+		p.keys.down.SetHelp("↓", "down")
+	}
+	p.filter()
+	return p
+}
+
+func (p *picker) keyMap() help.KeyMap {
+	return p.keys
+}
+
+func (p *picker) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	switch {
+	case key.Matches(msg, p.keys.cancel):
+		return true, nil
+	case key.Matches(msg, p.keys.choose):
+		if len(p.matches) == 0 {
+			return true, nil
+		}
+		return true, m.pick(p.kind, p.matches[p.cursor])
+	case key.Matches(msg, p.keys.up):
+		p.cursor = min(p.cursor+1, max(len(p.matches)-1, 0))
+	case key.Matches(msg, p.keys.down):
+		p.cursor = max(p.cursor-1, 0)
+	default:
+		prev := p.input.Value()
+		p.input, cmd = p.input.Update(msg)
+		if p.input.Value() != prev {
+			p.filter()
+		}
+	}
+	return false, cmd
+}
+
+// filter ranks the items against the query, best match first; ties keep the
+// original order.
+func (p *picker) filter() {
+	type match struct {
+		item  string
+		score int
+	}
+	query := p.input.Value()
+	var ms []match
+	for _, item := range p.items {
+		if score, ok := fuzzyMatch(query, item); ok {
+			ms = append(ms, match{item, score})
+		}
+	}
+	slices.SortStableFunc(ms, func(a, b match) int { return b.score - a.score })
+	p.matches = p.matches[:0]
+	for _, m := range ms {
+		p.matches = append(p.matches, m.item)
+	}
+	p.cursor = 0
+}
+
+// view renders the picker into a width×height box. The best match sits at
+// the bottom next to the query, like a shell's reverse search.
+func (p *picker) view(width, height int) string {
+	lines := make([]string, 0, height)
+	rows := max(height-2, 0)
+	first := max(p.cursor-rows+1, 0)
+	for i := min(first+rows, len(p.matches)) - 1; i >= first; i-- {
+		line := truncate(strings.ReplaceAll(p.matches[i], "\n", " "), width-2)
+		if i == p.cursor {
+			line = _pickerSelected.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < rows {
+		lines = slices.Insert(lines, 0, "")
+	}
+	lines = append(lines, _pickerTitle.Render(p.title), p.input.View())
+	return lipgloss.NewStyle().Width(width).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
+
+// fuzzyMatch reports whether the runes of pattern occur in s in order,
+// ignoring case. Consecutive runs and matches at word starts score higher.
+func fuzzyMatch(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	score, streak := 0, 0
+	prev := ' '
+	pr, size := utf8.DecodeRuneInString(pattern)
+	for _, r := range s {
+		if unicode.ToLower(r) == unicode.ToLower(pr) {
+			streak++
+			score += streak
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 2
+			}
+			pattern = pattern[size:]
+			if pattern == "" {
+				return score, true
+			}
+			pr, size = utf8.DecodeRuneInString(pattern)
+		} else {
+			streak = 0
+		}
+		prev = r
+	}
+	return 0, false
+}
+
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	rs := []rune(s)
+	for len(rs) > 0 && lipgloss.Width(string(rs))+1 > width {
+		rs = rs[:len(rs)-1]
+	}
+	return string(rs) + "…"
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// stage is a filter committed to the pipeline. Its result is the input of
+// the filter being edited.
+type stage struct {
+	filter  string
+	input   string
+	records int
+}
+
+// pipeline returns the stages and the current filter joined into a single
+// filter.
+func (m model) pipeline() string {
+	filters := make([]string, 0, len(m.stages)+1)
+	for _, s := range m.stages {
+		filters = append(filters, s.filter)
+	}
+	return strings.Join(append(filters, m.jqFilter()), " | ")
+}
+
+// pushStage commits the current filter as a pipeline stage and starts a
+// new filter on its result.
+func (m *model) pushStage() tea.Cmd {
+	switch {
+	case !m.upToDate() || m.evaluating():
+		m.setStatus(errors.New("evaluate the filter before adding a stage"), "")
+		return nil
+	case m.exitCode != 0:
+		m.setStatus(errors.New("cannot add a failing filter as a stage"), "")
+		return nil
+	case m.evalOptions.Raw:
+		m.setStatus(errors.New("raw output cannot be piped into a stage"), "")
+		return nil
+	}
+	// Tabs and the result history keep the stages they were left with, and
+	// may share their array after a pop.
+	m.stages = append(slices.Clip(m.stages), stage{filter: m.jqFilter(), input: m.content, records: m.records})
+	result := ansi.Strip(m.result)
+	return m.setInput(result, "", input.CountRecords(result))
+}
+
+// popStage drops the last stage and edits its filter again.
+func (m *model) popStage() tea.Cmd {
+	n := len(m.stages)
+	if n == 0 {
+		return nil
+	}
+	s := m.stages[n-1]
+	m.stages = m.stages[:n-1]
+	return m.setInput(s.input, s.filter, s.records)
+}
+
+// pipelineInput returns the input of the first stage of the pipeline.
+func (m model) pipelineInput() string {
+	if len(m.stages) > 0 {
+		return m.stages[0].input
+	}
+	return m.content
+}
+
+// drillDown makes the current result the input document, forgetting the
+// filters that led to it. Unlike a pipeline stage, the filter printed on
+// exit then applies to the narrowed input.
+func (m *model) drillDown() tea.Cmd {
+	switch {
+	case !m.upToDate() || m.evaluating():
+		m.setStatus(errors.New("evaluate the filter before using its result"), "")
+		return nil
+	case m.exitCode != 0:
+		m.setStatus(errors.New("cannot use the result of a failing filter"), "")
+		return nil
+	case m.evalOptions.Raw:
+		m.setStatus(errors.New("raw output cannot be used as input"), "")
+		return nil
+	}
+	m.stages = nil
+	result := ansi.Strip(m.result)
+	cmd := m.setInput(result, "", input.CountRecords(result))
+	m.setStatus(nil, "using the result of %s as input, alt+z to reset", m.resultFilter)
+	return cmd
+}
+
+// resetInput goes back to the document ijq was started on, keeping the
+// current filter.
+func (m *model) resetInput() tea.Cmd {
+	if m.content == m.rootContent && len(m.stages) == 0 {
+		return nil
+	}
+	m.stages = nil
+	cmd := m.setInput(m.rootContent, m.filterValue(), m.rootRecords)
+	m.setStatus(nil, "reset to the original input")
+	return cmd
+}
+
+// setInput replaces the input document, which has the given number of
+// JSON values, and the filter, and evaluates it.
+func (m *model) setInput(content, filter string, records int) tea.Cmd {
+	m.setContent(content, records)
+	m.setFilterValue(filter)
+	m.resize()
+	return m.startEval()
+}
+
+// setContent replaces the input document, which has the given number of
+// JSON values.
+func (m *model) setContent(content string, records int) {
+	m.content = content
+	m.keyIndex = nil
+	m.records = records
+	m.original, m.showOriginal = "", false
+	m.refreshSource()
+}
+
+// stagesView renders the breadcrumb of pipeline stages, or nothing if there
+// are none.
+func (m model) stagesView() string {
+	if len(m.stages) == 0 {
+		return ""
+	}
+	crumbs := make([]string, 0, len(m.stages))
+	for _, s := range m.stages {
+		crumbs = append(crumbs, truncate(strings.ReplaceAll(s.filter, "\n", " "), 30))
+	}
+	return truncate(_statusInfo.Render(strings.Join(crumbs, " › ")+" ›"), m.width)
+}
+
+// headerView renders the document and tab bars and the pipeline breadcrumb
+// shown above the filter.
+func (m model) headerView() string {
+	var lines []string
+	for _, s := range []string{m.documentsView(), m.tabsView(), m.stagesView()} {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// DefaultRedactKeys matches the object keys whose values --redact masks.
+const DefaultRedactKeys = `(?i)password|token|secret|authorization`
+
+// _redacted replaces a masked value. It is a JSON string, so that the tree
+// view can still parse a redacted result.
+const _redacted = `"••••••"`
+
+// redactJSON masks the values of the object keys in s that match keys, and
+// every value nested in them, leaving the keys and any colors as they are.
+// s is jq output, possibly colored; text that is not JSON passes through.
+func redactJSON(s string, keys *regexp.Regexp) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	depth := 0
+	// maskDepth is the depth of the container being masked, or -1; pending
+	// is set after a matching key until its value.
+	maskDepth, pending := -1, false
+	masking := func() bool { return pending || maskDepth >= 0 }
+	for i := 0; i < len(s); {
+		if n := ansiutil.EscapeLen(s[i:]); n > 0 {
+			sb.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		switch c := s[i]; c {
+		case ' ', '\t', '\n', '\r', ':':
+			sb.WriteByte(c)
+			i++
+		case ',':
+			pending = false
+			sb.WriteByte(c)
+			i++
+		case '{', '[':
+			depth++
+			if pending {
+				maskDepth, pending = depth, false
+			}
+			sb.WriteByte(c)
+			i++
+		case '}', ']':
+			if depth == maskDepth {
+				maskDepth = -1
+			}
+			depth = max(depth-1, 0)
+			pending = false
+			sb.WriteByte(c)
+			i++
+		case '"':
+			end := engine.StringEnd(s, i)
+			if engine.IsKey(s[end:]) {
+				if maskDepth < 0 {
+					pending = keys.MatchString(s[i+1 : end-1])
+				}
+				sb.WriteString(s[i:end])
+			} else if masking() {
+				sb.WriteString(_redacted)
+				pending = false
+			} else {
+				sb.WriteString(s[i:end])
+			}
+			i = end
+		default:
+			end := i + 1
+			for end < len(s) && !strings.ContainsRune(" \t\n\r,:{}[]\"\x1b", rune(s[end])) {
+				end++
+			}
+			if masking() {
+				sb.WriteString(_redacted)
+				pending = false
+			} else {
+				sb.WriteString(s[i:end])
+			}
+			i = end
+		}
+	}
+	return sb.String()
+}
+
+// redacted returns s with secrets masked while redaction is on.
+func (m model) redacted(s string) string {
+	if !m.redact {
+		return s
+	}
+	return redactJSON(s, m.redactKeys)
+}
+
+func (m *model) toggleRedact() {
+	m.redact = !m.redact
+	m.updateYAML()
+	m.updateGron()
+	m.updateElements()
+	if m.split {
+		m.refreshSource()
+	}
+	m.setStatus(nil, "redaction %s", onOff(m.redact))
+	m.updateView()
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	keys := regexp.MustCompile(DefaultRedactKeys)
+	const m = _redacted
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"string", `{"user":"bob","password":"x"}`, `{"user":"bob","password":` + m + `}`},
+		{"scalars", `{"token":1,"secret":null,"Authorization":true}`, `{"token":` + m + `,"secret":` + m + `,"Authorization":` + m + `}`},
+		{"key case", `{"API_TOKEN":"t"}`, `{"API_TOKEN":` + m + `}`},
+		{"nested object", `{"token":{"a":1,"b":[2,"c"]},"d":4}`, `{"token":{"a":` + m + `,"b":[` + m + `,` + m + `]},"d":4}`},
+		{"array", `{"secrets":["a","b"],"e":"f"}`, `{"secrets":[` + m + `,` + m + `],"e":"f"}`},
+		{"deeper", `[{"x":{"password":"p","y":"q"}}]`, `[{"x":{"password":` + m + `,"y":"q"}}]`},
+		{"values are not keys", `["password",{"a":"token"}]`, `["password",{"a":"token"}]`},
+		{"escaped key", `{"a\"password":"p"}`, `{"a\"password":` + m + `}`},
+		{"pretty", "{\n  \"token\": \"t\",\n  \"n\": 1\n}\n", "{\n  \"token\": " + m + ",\n  \"n\": 1\n}\n"},
+		{"several values", "{\"token\":1}\n{\"token\":2}\n", "{\"token\":" + m + "}\n{\"token\":" + m + "}\n"},
+		{"colored",
+			"\x1b[1;39m{\x1b[0m\x1b[34;1m\"token\"\x1b[0m\x1b[1;39m:\x1b[0m\x1b[0;32m\"abc\"\x1b[0m\x1b[1;39m}\x1b[0m",
+			"\x1b[1;39m{\x1b[0m\x1b[34;1m\"token\"\x1b[0m\x1b[1;39m:\x1b[0m\x1b[0;32m" + m + "\x1b[0m\x1b[1;39m}\x1b[0m"},
+		{"not JSON", "jq: error: token is not defined\n", "jq: error: token is not defined\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactJSON(tt.in, keys); got != tt.want {
+				t.Errorf("redactJSON(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// _maxLoggedResult caps how much of a single result is written to the log.
+const _maxLoggedResult = 64 << 10
+
+// resultLog is an append-only record of filters and their results.
+type resultLog struct {
+	path string
+}
+
+func (l *resultLog) append(now time.Time, filter string, opts engine.Options, result string) error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(formatLogEntry(now, filter, opts, result))
+	return err
+}
+
+// logResult appends the filter and its result, or its errors if it failed,
+// to the log. The filter must have been evaluated.
+func (m *model) logResult() {
+	m.logEval = 0
+	result := m.result
+	if m.exitCode != 0 {
+		result = m.errText
+	}
+	err := m.resultLog.append(time.Now(), m.pipeline(), m.evalOptions, result)
+	m.setStatus(err, "logged result to %s", m.resultLog.path)
+}
+
+func formatLogEntry(now time.Time, filter string, opts engine.Options, result string) string {
+	result = ansi.Strip(result)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "filter: %s\n", filter)
+	if flags := opts.Flags(); len(flags) > 0 {
+		fmt.Fprintf(&sb, "options: %s\n", strings.Join(flags, " "))
+	}
+	if n := len(result); n > _maxLoggedResult {
+		cut := _maxLoggedResult
+		for cut > 0 && !utf8.RuneStart(result[cut]) {
+			cut--
+		}
+		result = fmt.Sprintf("%s\n... (truncated, %d of %d bytes shown)", result[:cut], cut, n)
+	}
+	sb.WriteString(result)
+	if !strings.HasSuffix(result, "\n") {
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
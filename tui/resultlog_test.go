@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/maolonglong/ijq/engine"
+)
+
+func TestFormatLogEntry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter string
+		opts   engine.Options
+		result string
+		want   string
+	}{
+		{"result", ".a", engine.Options{}, "1\n", "--- 2024-05-01T12:00:00Z\nfilter: .a\n1\n\n"},
+		{"no final newline", ".a", engine.Options{}, "1", "--- 2024-05-01T12:00:00Z\nfilter: .a\n1\n\n"},
+		{"options", ".", engine.Options{Slurp: true}, "[]\n", "--- 2024-05-01T12:00:00Z\nfilter: .\noptions: --slurp\n[]\n\n"},
+		{"colors", ".", engine.Options{}, "\x1b[1;39m{}\x1b[0m\n", "--- 2024-05-01T12:00:00Z\nfilter: .\n{}\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLogEntry(now, tt.filter, tt.opts, tt.result); got != tt.want {
+				t.Errorf("formatLogEntry(%q, %q) = %q, want %q", tt.filter, tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatLogEntryTruncated checks that a long result is cut at the start
+// of a rune.
+func TestFormatLogEntryTruncated(t *testing.T) {
+	for _, pad := range []int{0, 1, 2} {
+		result := strings.Repeat("x", pad) + strings.Repeat("é", _maxLoggedResult)
+		got := formatLogEntry(time.Now(), ".", engine.Options{}, result)
+		if !utf8.ValidString(got) {
+			t.Errorf("entry with %d bytes before the 2-byte runes is not valid UTF-8", pad)
+		}
+		if !strings.Contains(got, "... (truncated, ") {
+			t.Errorf("entry with %d bytes before the 2-byte runes is not truncated", pad)
+		}
+	}
+}
+
+func TestResultLogPermissions(t *testing.T) {
+	l := &resultLog{path: filepath.Join(t.TempDir(), "log")}
+	if err := l.append(time.Now(), ".", engine.Options{}, "1\n"); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(l.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("log file mode %v, want it private to the user", perm)
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"cmp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Limits of the result history of a tab: the number of results kept, and
+// their total size.
+const (
+	_resultHistoryEntries = 50
+	_resultHistoryBytes   = 64 << 20
+)
+
+// resultHistory holds the latest results of a tab with their filters, to
+// go back and forward through like the pages of a browser.
+type resultHistory struct {
+	entries []tab
+	// current is the entry shown.
+	current int
+}
+
+// record adds the result just evaluated after the one shown, dropping any
+// that were gone back from. The result of the same filter and options as
+// the one shown replaces it instead.
+func (h *resultHistory) record(t tab) {
+	if len(h.entries) > 0 && h.entries[h.current].evaluated == t.evaluated {
+		h.entries[h.current] = t
+		return
+	}
+	if len(h.entries) > 0 {
+		h.entries = h.entries[:h.current+1]
+	}
+	h.entries = append(h.entries, t)
+	size := 0
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		size += len(h.entries[i].result)
+		// The newest result is kept whatever its size.
+		if i < len(h.entries)-1 && (size > _resultHistoryBytes || len(h.entries)-i > _resultHistoryEntries) {
+			h.entries = h.entries[i+1:]
+			break
+		}
+	}
+	h.current = len(h.entries) - 1
+}
+
+// recordResult adds the result just evaluated to the result history.
+func (m *model) recordResult() {
+	m.results.record(m.snapshot())
+}
+
+// stepResult goes back, with delta -1, or forward through the result
+// history, restoring both the filter and its result without evaluating it
+// again.
+func (m *model) stepResult(delta int) tea.Cmd {
+	h := &m.results
+	i := h.current + delta
+	if i < 0 || i >= len(h.entries) {
+		if delta < 0 {
+			m.setStatus(nil, "no older result")
+		} else {
+			m.setStatus(nil, "no newer result")
+		}
+		return nil
+	}
+	m.stopEval()
+	// Remember where the result was scrolled to, unless its filter has
+	// been changed since.
+	if h.entries[h.current].evaluated == m.evaluated {
+		h.entries[h.current] = m.snapshot()
+	}
+	h.current = i
+	m.restore(h.entries[i])
+	filter := cmp.Or(strings.TrimSpace(h.entries[i].filter), ".")
+	m.setStatus(nil, "result %d of %d: %s", i+1, len(h.entries), strings.ReplaceAll(filter, "\n", " "))
+	if m.upToDate() {
+		return nil
+	}
+	return m.startEval()
+}
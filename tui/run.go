@@ -0,0 +1,133 @@
+// Package tui is ijq's interactive jq filter picker, for embedding in other
+// tools: Run shows it on a terminal and returns the filter the user chose.
+package tui
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// Document is an input document, one of several to switch between.
+type Document = input.Document
+
+// Result is what the user chose in Run.
+type Result struct {
+	// Accepted is set if the user accepted the filter rather than quit.
+	Accepted bool
+	// Filter is the filter accepted, with the stages of a pipeline joined.
+	Filter string
+	// Output is the result of Filter without colors, in the output format,
+	// and Errors what jq printed to stderr for it. They are only set if
+	// the output mode asks for the result.
+	Output string
+	Errors string
+	// ExitCode is jq's exit status for Filter, as last evaluated unless
+	// the output mode asks for the result.
+	ExitCode int
+	// Options are the options as the user left them, such as the toggles
+	// switched and the filter typed, to start again from.
+	Options Options
+}
+
+// Run shows the interface on opts.Term, or stderr if unset, until the user
+// accepts a filter or quits. The engine, output and scroll modes, split
+// ratio, keys and redacted keys default to those of the CLI when left zero,
+// with jq, or gojq if jq is not installed, as the engine; other options
+// left zero are off. The engine, which may
+// be switched from the command palette, is closed on return.
+func Run(opts Options) (Result, error) {
+	if err := opts.setDefaults(); err != nil {
+		return Result{}, err
+	}
+	progOpts := []tea.ProgramOption{tea.WithOutput(opts.Term), tea.WithAltScreen()}
+	if !opts.NoMouse {
+		progOpts = append(progOpts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(newModel(opts.Input, opts), progOpts...)
+	defer notifySuspend(p)()
+
+	tm, err := p.Run()
+	if err != nil {
+		opts.Engine.Close()
+		return Result{}, err
+	}
+	m := tm.(model)
+	res := Result{
+		Accepted: m.accepted,
+		Filter:   m.pipeline(),
+		ExitCode: m.exitCode,
+		Options:  m.options(opts),
+	}
+	if m.accepted && m.outputMode != OutputFilter {
+		res.Output, res.Errors, res.ExitCode = m.output()
+	}
+	m.engine.Close()
+	res.Options.Engine = nil
+	return res, nil
+}
+
+// setDefaults fills in the options left zero that have a default.
+func (o *Options) setDefaults() error {
+	if o.Engine == nil {
+		eng, err := engine.New(o.EngineName, o.JQPath)
+		if err != nil {
+			return err
+		}
+		o.Engine = eng
+	}
+	if o.RedactKeys == nil {
+		o.RedactKeys = regexp.MustCompile(DefaultRedactKeys)
+	}
+	if o.Keys == nil {
+		keys := DefaultKeyMap()
+		o.Keys = &keys
+	}
+	if o.Term == nil {
+		o.Term = os.Stderr
+	}
+	o.OutputMode = cmp.Or(o.OutputMode, OutputFilter)
+	o.ScrollMode = cmp.Or(o.ScrollMode, ScrollAuto)
+	o.SplitRatio = cmp.Or(o.SplitRatio, DefaultSplit)
+	return nil
+}
+
+// options returns opts, which m was started with, updated to the state m
+// was left in.
+func (m model) options(opts Options) Options {
+	opts.Options = m.evalOptions
+	opts.Options.Output = nil
+	opts.Filter = m.filterValue()
+	opts.OutputMode = m.outputMode
+	opts.OutputYAML = m.outputYAML
+	opts.Live = m.live
+	opts.LineNumbers = m.lineNumbers
+	opts.Wrap = m.wrap
+	opts.Redact = m.redact
+	opts.SplitRatio = m.splitRatio
+	opts.YOffset = m.viewport.YOffset
+	return opts
+}
+
+// output evaluates the accepted filter again, so that the result matches
+// it even if the last evaluation was stale or still running.
+func (m model) output() (output, errs string, exitCode int) {
+	ctx, cancel := m.evalContext()
+	res := m.engine.Eval(ctx, m.pipelineInput(), m.pipeline(), m.evalOptions)
+	cancel()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		res = timedOut(m.timeout)
+	}
+	output = ansi.Strip(res.Output)
+	if m.outputYAML {
+		output = toYAML(output)
+	}
+	return output, res.Errors, res.ExitCode
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+type saveKeyMap struct {
+	save      key.Binding
+	cancel    key.Binding
+	overwrite key.Binding
+	keep      key.Binding
+}
+
+func (k saveKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.save, k.cancel, k.overwrite, k.keep}
+}
+
+func (k saveKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// savePrompt is an overlay asking for the file to write the result to. It
+// asks again before replacing an existing file.
+type savePrompt struct {
+	input   textinput.Model
+	keys    saveKeyMap
+	confirm bool
+	err     error
+}
+
+func newSavePrompt() *savePrompt {
+	ti := textinput.New()
+	ti.Prompt = "file: "
+	ti.Focus()
+	p := &savePrompt{
+		input: ti,
+		keys: saveKeyMap{
+			save: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "save"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+g", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+			overwrite: key.NewBinding(
+				key.WithKeys("y"),
+				key.WithHelp("y", "overwrite"),
+			),
+			keep: key.NewBinding(
+				key.WithKeys("n"),
+				key.WithHelp("n", "choose another name"),
+			),
+		},
+	}
+	p.setConfirm(false)
+	return p
+}
+
+func (p *savePrompt) keyMap() help.KeyMap {
+	return p.keys
+}
+
+// setConfirm switches between typing the file name and confirming that
+// the existing file may be replaced.
+func (p *savePrompt) setConfirm(confirm bool) {
+	p.confirm = confirm
+	p.keys.save.SetEnabled(!confirm)
+	p.keys.overwrite.SetEnabled(confirm)
+	p.keys.keep.SetEnabled(confirm)
+}
+
+func (p *savePrompt) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	name := strings.TrimSpace(p.input.Value())
+	switch {
+	case key.Matches(msg, p.keys.cancel):
+		return true, nil
+	case key.Matches(msg, p.keys.overwrite):
+		return p.write(m, name, true)
+	case key.Matches(msg, p.keys.keep):
+		p.setConfirm(false)
+	case key.Matches(msg, p.keys.save):
+		if name == "" {
+			return false, nil
+		}
+		return p.write(m, name, false)
+	case !p.confirm:
+		p.err = nil
+		p.input, cmd = p.input.Update(msg)
+	}
+	return false, cmd
+}
+
+func (p *savePrompt) write(m *model, name string, overwrite bool) (bool, tea.Cmd) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	result := m.resultText()
+	err := writeFile(name, flags, result)
+	if errors.Is(err, fs.ErrExist) {
+		p.setConfirm(true)
+		return false, nil
+	}
+	p.setConfirm(false)
+	if err != nil {
+		p.err = err
+		return false, nil
+	}
+	m.setStatus(nil, "saved %s to %s", input.FormatBytes(len(result)), name)
+	return true, nil
+}
+
+func writeFile(name string, flags int, s string) error {
+	f, err := os.OpenFile(name, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (p *savePrompt) view(width, height int) string {
+	lines := []string{_pickerTitle.Render("save result"), p.input.View()}
+	if p.confirm {
+		lines = append(lines, fmt.Sprintf("%s exists, overwrite? (y/n)", strings.TrimSpace(p.input.Value())))
+	}
+	if p.err != nil {
+		lines = append(lines, _errorText.Render(p.err.Error()))
+	}
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
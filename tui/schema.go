@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// _schemaKinds orders the types of a value in a schema.
+var _schemaKinds = []string{"null", "boolean", "number", "string", "object", "array"}
+
+// schema is the shape of the values seen at one place in the result: the
+// types they had, the fields of those that were objects, and the shape of
+// the elements of those that were arrays.
+type schema struct {
+	kinds map[string]bool
+	// objects is how many of the values were objects, so that a field seen
+	// in fewer of them is optional.
+	objects int
+	keys    []string
+	fields  map[string]*field
+	items   *schema
+}
+
+type field struct {
+	schema *schema
+	count  int
+}
+
+func newSchema() *schema {
+	return &schema{kinds: map[string]bool{}, fields: map[string]*field{}}
+}
+
+// inferSchema merges the shapes of the JSON values printed by jq, keeping
+// the fields in the order they were first printed.
+func inferSchema(result string) (*schema, int, error) {
+	t, err := newTree(result, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	s := newSchema()
+	for _, n := range t.roots {
+		s.add(n)
+	}
+	return s, len(t.roots), nil
+}
+
+func (s *schema) add(n *treeNode) {
+	switch {
+	case n.object:
+		s.kinds["object"] = true
+		s.objects++
+		for _, c := range n.children {
+			f, ok := s.fields[c.key]
+			if !ok {
+				f = &field{schema: newSchema()}
+				s.fields[c.key] = f
+				s.keys = append(s.keys, c.key)
+			}
+			f.schema.add(c)
+			f.count++
+		}
+	case n.container:
+		s.kinds["array"] = true
+		if s.items == nil {
+			s.items = newSchema()
+		}
+		for _, c := range n.children {
+			s.items.add(c)
+		}
+	default:
+		switch n.value.(type) {
+		case nil:
+			s.kinds["null"] = true
+		case bool:
+			s.kinds["boolean"] = true
+		case json.Number:
+			s.kinds["number"] = true
+		case string:
+			s.kinds["string"] = true
+		}
+	}
+}
+
+// describe returns the types of s, such as string | null or array of
+// number.
+func (s *schema) describe() string {
+	var kinds []string
+	for _, k := range _schemaKinds {
+		if !s.kinds[k] {
+			continue
+		}
+		if k == "array" {
+			if s.items == nil || len(s.items.kinds) == 0 {
+				k = "array (empty)"
+			} else {
+				k = "array of " + s.items.describe()
+			}
+		}
+		kinds = append(kinds, k)
+	}
+	if len(kinds) == 0 {
+		return "nothing"
+	}
+	d := strings.Join(kinds, " | ")
+	if len(kinds) > 1 && strings.Contains(d, "array of") {
+		return "(" + d + ")"
+	}
+	return d
+}
+
+// object returns the schema whose fields are listed below s: its own, or
+// those of the objects in its arrays.
+func (s *schema) object() *schema {
+	for s != nil && !s.kinds["object"] {
+		s = s.items
+	}
+	return s
+}
+
+// lines renders the fields of s, indented by depth, with a ? after those
+// missing from some of the objects.
+func (s *schema) lines(depth int) []string {
+	o := s.object()
+	if o == nil {
+		return nil
+	}
+	var lines []string
+	indent := strings.Repeat("  ", depth)
+	for _, k := range o.keys {
+		f := o.fields[k]
+		name := k
+		if !isIdent(k) {
+			b, _ := json.Marshal(k)
+			name = string(b)
+		}
+		if f.count < o.objects {
+			name += "?"
+		}
+		lines = append(lines, fmt.Sprintf("%s%s: %s", indent, name, f.schema.describe()))
+		lines = append(lines, f.schema.lines(depth+1)...)
+	}
+	return lines
+}
+
+// newSchemaOverlay describes the shape of the result in a text overlay.
+func newSchemaOverlay(result string, closeKeys []string, keys viewport.KeyMap) (*textOverlay, error) {
+	s, n, err := inferSchema(result)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errors.New("no result to describe")
+	}
+	title := "schema"
+	if n > 1 {
+		title = fmt.Sprintf("schema of %d values", n)
+	}
+	lines := []string{_pickerTitle.Render(title), s.describe()}
+	lines = append(lines, s.lines(1)...)
+	return newTextOverlay(lines, closeKeys, keys), nil
+}
@@ -0,0 +1,77 @@
+package tui
+
+import "github.com/charmbracelet/x/ansi"
+
+// Where the result viewport scrolls to after an evaluation, as set with
+// --scroll.
+const (
+	ScrollAuto = "auto"
+	ScrollKeep = "keep"
+	ScrollTop  = "top"
+)
+
+// ScrollModes are the values of --scroll.
+var ScrollModes = []string{ScrollAuto, ScrollKeep, ScrollTop}
+
+// _scrollWindow is how many lines up or down auto mode looks for the line
+// that was at the top of the viewport in a new result.
+const _scrollWindow = 200
+
+// scrollAnchor is the line at the top of the result viewport, to scroll
+// back to once the result changes.
+type scrollAnchor struct {
+	line int
+	text string
+	// skip is how many rows of the line, when wrapped, are scrolled past.
+	skip    int
+	xOffset int
+}
+
+// scrollAnchor returns the line at the top of the viewport.
+func (m model) scrollAnchor() scrollAnchor {
+	if len(m.lines) == 0 {
+		return scrollAnchor{}
+	}
+	line := m.lineAt(m.viewport.YOffset)
+	return scrollAnchor{
+		line:    line,
+		text:    ansi.Strip(m.lines[line]),
+		skip:    m.viewport.YOffset - m.rowOf(line),
+		xOffset: m.xOffset,
+	}
+}
+
+// restoreScroll scrolls a new result back to a, which resetView left at
+// the top. keep mode goes back to the same line number. auto mode only
+// goes back if the line is still there, or moved by up to _scrollWindow
+// lines, as when tweaking a filter whose output stays similar; the top of
+// a different output is a better start.
+func (m *model) restoreScroll(mode string, a scrollAnchor) {
+	if mode == ScrollTop || a.line == 0 && a.skip == 0 && a.xOffset == 0 {
+		return
+	}
+	// Load the part of a result cut by --max-lines the line was in.
+	m.showLine(a.line)
+	line := min(a.line, max(len(m.lines)-1, 0))
+	if mode == ScrollAuto {
+		var ok bool
+		if line, ok = m.findNear(a.text, a.line); !ok {
+			return
+		}
+	}
+	m.viewport.SetYOffset(m.rowOf(line) + a.skip)
+	m.scrollColumns(a.xOffset)
+}
+
+// findNear returns the line of the result with the given text that is
+// closest to line, looking up to _scrollWindow lines either way.
+func (m model) findNear(text string, line int) (int, bool) {
+	for d := 0; d <= _scrollWindow; d++ {
+		for _, i := range []int{line - d, line + d} {
+			if i >= 0 && i < len(m.lines) && ansi.Strip(m.lines[i]) == text {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,338 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/internal/ansiutil"
+)
+
+// SGR sequences used to highlight matches. They only touch the background,
+// underline and reverse attributes, which jq's colors leave alone. Matches
+// are underlined instead of colored on a monochrome terminal.
+const (
+	_matchOn      = "\x1b[43m"
+	_matchOff     = "\x1b[49m"
+	_matchMonoOn  = "\x1b[4m"
+	_matchMonoOff = "\x1b[24m"
+	_currentOn    = "\x1b[7m"
+	_currentOff   = "\x1b[27m"
+)
+
+// searchMatch is the byte range [start, end) of a match within the
+// uncolored text of a result line.
+type searchMatch struct {
+	line, start, end int
+}
+
+type searchKeyMap struct {
+	confirm     key.Binding
+	cancel      key.Binding
+	toggleRegex key.Binding
+	toggleCase  key.Binding
+}
+
+func (k searchKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.confirm, k.cancel, k.toggleRegex, k.toggleCase}
+}
+
+func (k searchKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// search holds the state of searching inside the result viewport.
+type search struct {
+	input    textinput.Model
+	keys     searchKeyMap
+	query    string
+	regex    bool
+	caseMode caseMode
+	err      error
+	matches  []searchMatch
+	current  int
+	// prompting is set while the query is being typed; origin is the
+	// viewport offset to return to if it is cancelled.
+	prompting bool
+	origin    int
+	// monochrome underlines the matches rather than coloring them.
+	monochrome bool
+}
+
+func newSearch() search {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	return search{
+		input: ti,
+		keys: searchKeyMap{
+			confirm: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "confirm"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+			toggleRegex: key.NewBinding(
+				key.WithKeys("alt+x"),
+				key.WithHelp("alt+x", "regex"),
+			),
+			toggleCase: key.NewBinding(
+				key.WithKeys("alt+i"),
+				key.WithHelp("alt+i", "case"),
+			),
+		},
+	}
+}
+
+func (s search) keyMap() help.KeyMap {
+	return s.keys
+}
+
+// caseMode controls whether searches distinguish upper and lower case.
+type caseMode int
+
+const (
+	// caseSmart ignores case unless the query has upper-case letters.
+	caseSmart caseMode = iota
+	caseIgnore
+	caseMatch
+)
+
+func (c caseMode) String() string {
+	switch c {
+	case caseIgnore:
+		return "ignore case"
+	case caseMatch:
+		return "match case"
+	default:
+		return "smart case"
+	}
+}
+
+// pattern compiles the query, quoting it unless regex mode is on.
+func (s search) pattern() (*regexp.Regexp, error) {
+	expr := s.query
+	if !s.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if s.caseMode == caseIgnore || s.caseMode == caseSmart && !strings.ContainsFunc(s.query, unicode.IsUpper) {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// find locates every match of the query in the uncolored lines of content.
+// Empty matches are skipped.
+func (s *search) find(content string) {
+	s.matches = s.matches[:0]
+	s.current = 0
+	s.err = nil
+	if s.query == "" {
+		return
+	}
+	re, err := s.pattern()
+	if err != nil {
+		s.err = err
+		return
+	}
+	for i, line := range strings.Split(content, "\n") {
+		for _, loc := range re.FindAllStringIndex(ansi.Strip(line), -1) {
+			if loc[0] < loc[1] {
+				s.matches = append(s.matches, searchMatch{line: i, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+}
+
+// counter describes the position of the current match, e.g. "match 3/17".
+func (s search) counter() string {
+	switch {
+	case s.query == "":
+		return ""
+	case s.err != nil:
+		return "invalid pattern"
+	case len(s.matches) == 0:
+		return "no matches"
+	default:
+		return fmt.Sprintf("match %d/%d", s.current+1, len(s.matches))
+	}
+}
+
+// promptView renders the query being typed along with the search modes.
+func (s search) promptView(width int) string {
+	info := s.caseMode.String()
+	if s.regex {
+		info = "regex · " + info
+	}
+	if c := s.counter(); c != "" {
+		info += " · " + c
+	}
+	s.input.Width = max(width-lipgloss.Width(info)-3, 1)
+	in := s.input.View()
+	gap := max(width-lipgloss.Width(in)-lipgloss.Width(info), 1)
+	return in + strings.Repeat(" ", gap) + _statusInfo.Render(info)
+}
+
+// lineMatches returns the indices of the matches in line.
+func (s search) lineMatches(line int) []int {
+	var idx []int
+	i := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= line })
+	for ; i < len(s.matches) && s.matches[i].line == line; i++ {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// highlightLine inserts highlighting for the matches idx into line, which
+// may contain SGR escape sequences; the current match is shown in reverse
+// video. It walks escape sequences and text separately so that offsets
+// refer to the uncolored text.
+func (s search) highlightLine(line string, idx []int) string {
+	matchOn, matchOff := _matchOn, _matchOff
+	if s.monochrome {
+		matchOn, matchOff = _matchMonoOn, _matchMonoOff
+	}
+	var sb strings.Builder
+	pos, k := 0, 0
+	inMatch := ""
+	for i := 0; i < len(line); {
+		if n := ansiutil.EscapeLen(line[i:]); n > 0 {
+			sb.WriteString(line[i : i+n])
+			// A reset inside the match turns the highlight off; restore it.
+			if inMatch != "" {
+				sb.WriteString(inMatch)
+			}
+			i += n
+			continue
+		}
+		if inMatch != "" && pos == s.matches[idx[k]].end {
+			sb.WriteString(matchOff + _currentOff)
+			inMatch = ""
+			k++
+		}
+		if inMatch == "" && k < len(idx) && pos == s.matches[idx[k]].start {
+			inMatch = matchOn
+			if idx[k] == s.current {
+				inMatch += _currentOn
+			}
+			sb.WriteString(inMatch)
+		}
+		sb.WriteByte(line[i])
+		pos++
+		i++
+	}
+	if inMatch != "" {
+		sb.WriteString(matchOff + _currentOff)
+	}
+	return sb.String()
+}
+
+// openSearch starts typing a new query in the result viewport.
+func (m *model) openSearch() tea.Cmd {
+	m.search.prompting = true
+	m.search.origin = m.viewport.YOffset
+	m.search.input.SetValue("")
+	m.resize()
+	return m.search.input.Focus()
+}
+
+// updateSearch handles a key press while the search query is typed,
+// updating the matches incrementally.
+func (m *model) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.search.keys.cancel):
+		m.search.prompting = false
+		m.search.input.Blur()
+		m.search.query = ""
+		m.search.matches = nil
+		m.viewport.SetYOffset(m.search.origin)
+		m.resize()
+		return nil
+	case key.Matches(msg, m.search.keys.confirm):
+		m.search.prompting = false
+		m.search.input.Blur()
+		m.resize()
+		if m.search.err != nil {
+			m.setStatus(m.search.err, "")
+		} else if len(m.search.matches) == 0 && m.search.query != "" {
+			m.setStatus(nil, "pattern not found: %s", m.search.query)
+		}
+		return nil
+	case key.Matches(msg, m.search.keys.toggleRegex):
+		m.search.regex = !m.search.regex
+		m.researchFrom(m.search.origin)
+		return nil
+	case key.Matches(msg, m.search.keys.toggleCase):
+		m.search.caseMode = (m.search.caseMode + 1) % 3
+		m.researchFrom(m.search.origin)
+		return nil
+	}
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	if q := m.search.input.Value(); q != m.search.query {
+		m.search.query = q
+		m.researchFrom(m.search.origin)
+	}
+	return cmd
+}
+
+// researchFrom finds the query again and moves to the first match at or
+// below viewport row row.
+func (m *model) researchFrom(row int) {
+	m.search.find(m.viewText())
+	m.search.current = m.firstMatchFrom(m.lineAt(row))
+	m.showMatch()
+}
+
+// firstMatchFrom returns the first match at or below the given line,
+// wrapping around to the top.
+func (m model) firstMatchFrom(line int) int {
+	for i, mt := range m.search.matches {
+		if mt.line >= line {
+			return i
+		}
+	}
+	return 0
+}
+
+// nextMatch moves to the next (or, with delta -1, previous) match.
+func (m *model) nextMatch(delta int) {
+	n := len(m.search.matches)
+	if n == 0 {
+		if m.search.query != "" {
+			m.setStatus(nil, "pattern not found: %s", m.search.query)
+		}
+		return
+	}
+	m.search.current = ((m.search.current+delta)%n + n) % n
+	m.showMatch()
+}
+
+// showMatch scrolls the current match into view, unless it already is.
+func (m *model) showMatch() {
+	if len(m.search.matches) == 0 {
+		return
+	}
+	mt := m.search.matches[m.search.current]
+	m.showLine(mt.line)
+	if !m.wrap {
+		text := ansi.Strip(m.lines[mt.line])
+		start, end := ansi.StringWidth(text[:mt.start]), ansi.StringWidth(text[:mt.end])
+		if start < m.xOffset || end > m.xOffset+m.textWidth {
+			m.xOffset = max(start-m.textWidth/3, 0)
+		}
+	}
+	row := m.rowOf(mt.line)
+	if row < m.viewport.YOffset || row >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(row - m.viewport.Height/3)
+	}
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// snippet is a named filter saved for reuse.
+type snippet struct {
+	Name   string `toml:"name"`
+	Filter string `toml:"filter"`
+}
+
+// String formats s as an item of the snippet picker.
+func (s snippet) String() string {
+	return s.Name + ": " + s.Filter
+}
+
+// defaultSnippetsPath returns $XDG_CONFIG_HOME/ijq/snippets.toml, falling
+// back to ~/.config/ijq/snippets.toml.
+func defaultSnippetsPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ijq", "snippets.toml"), nil
+}
+
+// loadSnippets reads the snippets file, a list of [[snippet]] tables with a
+// name and a filter each. A missing file holds no snippets.
+func loadSnippets(path string) ([]snippet, error) {
+	var file struct {
+		Snippet []snippet `toml:"snippet"`
+	}
+	if _, err := toml.DecodeFile(path, &file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return file.Snippet, nil
+}
+
+// appendSnippet adds s to the end of the snippets file, leaving the rest of
+// the file, comments included, untouched.
+func appendSnippet(path string, s snippet) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("\n[[snippet]]\n")
+	if err := toml.NewEncoder(&sb).Encode(s); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// openSnippets shows the saved snippets in a picker.
+func (m *model) openSnippets() {
+	path, err := defaultSnippetsPath()
+	if err == nil {
+		m.snippets, err = loadSnippets(path)
+	}
+	if err != nil {
+		m.setStatus(err, "")
+		return
+	}
+	if len(m.snippets) == 0 {
+		m.setStatus(nil, "no snippets yet, save one with ctrl+b")
+		return
+	}
+	items := make([]string, len(m.snippets))
+	for i, s := range m.snippets {
+		items[i] = s.String()
+	}
+	m.openOverlay(newPicker(pickSnippet, "snippets", items))
+}
+
+// pickedSnippet returns the filter of the snippet shown as item.
+func (m model) pickedSnippet(item string) string {
+	i := slices.IndexFunc(m.snippets, func(s snippet) bool { return s.String() == item })
+	if i < 0 {
+		return ""
+	}
+	return m.snippets[i].Filter
+}
+
+type snippetKeyMap struct {
+	save   key.Binding
+	cancel key.Binding
+}
+
+func (k snippetKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.save, k.cancel}
+}
+
+func (k snippetKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// snippetPrompt is an overlay asking for the name to save the filter under.
+type snippetPrompt struct {
+	filter string
+	input  textinput.Model
+	keys   snippetKeyMap
+	err    error
+}
+
+func newSnippetPrompt(filter string) *snippetPrompt {
+	ti := textinput.New()
+	ti.Prompt = "name: "
+	ti.Focus()
+	return &snippetPrompt{
+		filter: filter,
+		input:  ti,
+		keys: snippetKeyMap{
+			save: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "save"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+g", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+		},
+	}
+}
+
+func (p *snippetPrompt) keyMap() help.KeyMap {
+	return p.keys
+}
+
+func (p *snippetPrompt) update(m *model, msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	switch {
+	case key.Matches(msg, p.keys.cancel):
+		return true, nil
+	case key.Matches(msg, p.keys.save):
+		name := strings.TrimSpace(p.input.Value())
+		if name == "" {
+			return false, nil
+		}
+		if p.err = p.save(name); p.err != nil {
+			return false, nil
+		}
+		m.setStatus(nil, "saved snippet %s", name)
+		return true, nil
+	}
+	p.err = nil
+	p.input, cmd = p.input.Update(msg)
+	return false, cmd
+}
+
+func (p *snippetPrompt) save(name string) error {
+	path, err := defaultSnippetsPath()
+	if err != nil {
+		return err
+	}
+	snippets, err := loadSnippets(path)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(snippets, func(s snippet) bool { return s.Name == name }) {
+		return fmt.Errorf("snippet %s already exists", name)
+	}
+	return appendSnippet(path, snippet{Name: name, Filter: p.filter})
+}
+
+func (p *snippetPrompt) view(width, height int) string {
+	lines := []string{_pickerTitle.Render("save snippet"), truncate(p.filter, width), p.input.View()}
+	if p.err != nil {
+		lines = append(lines, _errorText.Render(p.err.Error()))
+	}
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
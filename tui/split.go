@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The left pane of the split view is resized in steps of _splitStep of the
+// screen, between MinSplit and MaxSplit.
+const (
+	DefaultSplit = 0.5
+	_splitStep   = 0.05
+	MinSplit     = 0.1
+	MaxSplit     = 0.9
+)
+
+// toggleSplit shows or hides the input document to the left of the result.
+func (m *model) toggleSplit() {
+	m.split = !m.split
+	if !m.split {
+		m.focusSource = false
+		m.pinned = nil
+	}
+	m.setStatus(nil, "split view %s", onOff(m.split))
+	m.refreshSource()
+}
+
+// pin is a result kept for comparison in the left pane.
+type pin struct {
+	filter string
+	result string
+}
+
+// togglePin pins the current result beside the result of the filter being
+// edited, or unpins it.
+func (m *model) togglePin() {
+	if m.pinned != nil {
+		m.pinned = nil
+		m.split = false
+		m.focusSource = false
+		m.setStatus(nil, "compare off")
+		return
+	}
+	m.pinned = &pin{filter: m.resultFilter, result: m.result}
+	m.split = true
+	m.setStatus(nil, "pinned %s", m.resultFilter)
+	m.refreshSource()
+}
+
+// cycleFocus moves the focus from the filter to the result, then to the
+// input document if it is shown, and back to the filter.
+func (m *model) cycleFocus() tea.Cmd {
+	switch {
+	case !m.focusViewport:
+		m.focusResult()
+	case m.split && !m.focusSource:
+		m.focusSource = true
+	default:
+		return m.focusFilter()
+	}
+	return nil
+}
+
+// focusResult moves the focus from the filter to the result.
+func (m *model) focusResult() {
+	m.blurInput()
+	m.focusViewport = true
+	m.focusChanged()
+}
+
+// focusFilter moves the focus back to the filter from either pane.
+func (m *model) focusFilter() tea.Cmd {
+	m.focusSource = false
+	m.focusViewport = false
+	m.focusChanged()
+	return m.focusInput()
+}
+
+// focusChanged updates what depends on the pane that has focus.
+func (m *model) focusChanged() {
+	m.completer.items = nil
+	m.keys.eval.SetEnabled(!m.focusViewport)
+	m.keys.focusViewport = m.focusViewport
+}
+
+// resultWidth returns the width of the result viewport, which shares the
+// screen with the input document in split view.
+func (m model) resultWidth() int {
+	if !m.split {
+		return m.width
+	}
+	return m.width - m.sourceWidth() - 1
+}
+
+// sourceWidth returns the width of the left pane in split view.
+func (m model) sourceWidth() int {
+	return int(float64(m.width) * m.splitRatio)
+}
+
+// resizeSplit moves the border between the panes of the split view by
+// delta of the screen width.
+func (m *model) resizeSplit(delta float64) {
+	if !m.split {
+		return
+	}
+	// Round away the drift of adding up steps.
+	m.splitRatio = math.Round(min(max(m.splitRatio+delta, MinSplit), MaxSplit)*100) / 100
+	m.setStatus(nil, "left pane %.0f%%", m.splitRatio*100)
+	m.resize()
+}
+
+// refreshSource lays out the input document, or the pinned result in
+// compare mode, for its pane, cutting lines that do not fit. Secrets are
+// masked while redaction is on.
+func (m *model) refreshSource() {
+	var sb strings.Builder
+	text := m.drawable(m.redacted(m.content))
+	if m.pinned != nil {
+		text = m.drawable(m.redacted(m.pinned.result))
+		sb.WriteString(_statusInfo.Render(ansi.Truncate("pinned: "+m.pinned.filter, m.source.Width, "…")))
+		sb.WriteByte('\n')
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		sb.WriteString(ansi.Truncate(line, m.source.Width, "…"))
+		sb.WriteByte('\n')
+	}
+	m.source.SetContent(sb.String())
+}
+
+// resizeSource fits the input document pane to the left half of the
+// screen.
+func (m *model) resizeSource() {
+	if !m.split {
+		return
+	}
+	if m.source.Width == 0 && m.source.Height == 0 {
+		m.source = viewport.New(0, 0)
+		m.source.KeyMap = m.keys.viewport
+	}
+	m.source.Height = m.viewport.Height
+	if w := m.sourceWidth(); w != m.source.Width {
+		m.source.Width = w
+		m.refreshSource()
+	}
+}
+
+// splitView draws the input document and the result side by side.
+func (m model) splitView() string {
+	sep := _gutter.Render(strings.TrimSuffix(strings.Repeat("│\n", m.viewport.Height), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.source.View(), sep, m.resultView())
+}
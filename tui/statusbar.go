@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+var _statusInfo = lipgloss.NewStyle().Faint(true)
+
+// statusView renders the status bar: the last status message, or the error
+// of a failed evaluation, on the left and statistics about the result and
+// the active jq toggles on the right.
+func (m model) statusView() string {
+	info := m.statusInfo()
+	status := m.status
+	failed := m.exitCode != 0 && !m.evaluating()
+	if failed {
+		msg := fmt.Sprintf("✗ exit status %d", m.exitCode)
+		if m.resultFilter != "" {
+			msg += ", showing result of " + m.resultFilter
+		}
+		if status != "" {
+			msg += " · " + status
+		}
+		status = msg
+	}
+	status = truncate(status, max(m.width-lipgloss.Width(info)-1, 0))
+	gap := max(m.width-lipgloss.Width(status)-lipgloss.Width(info), 0)
+	if failed {
+		status = _errorText.Render(status)
+	}
+	return status + strings.Repeat(" ", gap) + _statusInfo.Render(info)
+}
+
+// statusInfo summarizes the last evaluation and the viewport position.
+func (m model) statusInfo() string {
+	duration := formatDuration(m.evalTime)
+	if m.cached {
+		duration = "cached"
+	}
+	lines := fmt.Sprintf("%d lines", m.resultLines)
+	if m.bar {
+		// Where the top of the viewport is, in the lines shown.
+		lines = fmt.Sprintf("line %s of %s", formatCount(m.lineAt(m.viewport.YOffset)+1), formatCount(len(m.lines)+m.hiddenLines))
+	}
+	parts := []string{
+		duration,
+		fmt.Sprintf("exit %d", m.exitCode),
+		input.FormatBytes(m.resultBytes),
+		lines,
+		fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100),
+	}
+	if m.pinned != nil {
+		if m.pinned.result == m.result {
+			parts = append([]string{"same as pinned"}, parts...)
+		} else {
+			parts = append([]string{"differs from pinned"}, parts...)
+		}
+	}
+	if m.showOriginal {
+		parts = append([]string{"input"}, parts...)
+	}
+	if m.records > 1 {
+		mode := "per record"
+		if m.evalOptions.Slurp {
+			mode = "slurped"
+		}
+		parts = append([]string{fmt.Sprintf("%d records, %s", m.records, mode)}, parts...)
+	}
+	if m.follow != nil {
+		if m.autoScroll {
+			parts = append(parts, "following")
+		} else {
+			parts = append(parts, "following, no auto-scroll")
+		}
+	}
+	if m.redact {
+		parts = append(parts, "redacted")
+	}
+	if m.xOffset > 0 {
+		parts = append(parts, fmt.Sprintf("col %d", m.xOffset+1))
+	}
+	if c := m.elements.counter(); c != "" {
+		parts = append([]string{c}, parts...)
+	}
+	if c := m.search.counter(); c != "" {
+		parts = append([]string{c}, parts...)
+	}
+	info := strings.Join(parts, " · ")
+	if ts := m.evalOptions.Toggles(); len(ts) > 0 {
+		info += " [" + strings.Join(ts, " ") + "]"
+	}
+	return info
+}
+
+// setStatus shows a one-line message in the status bar, or err if non-nil.
+func (m *model) setStatus(err error, format string, args ...any) {
+	if err != nil {
+		m.status = "error: " + err.Error()
+	} else {
+		m.status = fmt.Sprintf(format, args...)
+	}
+	m.resize()
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
+
+// formatCount formats n with thousands separators, e.g. 1,234,567.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/maolonglong/ijq/engine"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// _streamDelay is how long an evaluation runs before the output it has
+// produced so far is shown, and _streamPreview how much of it is laid out.
+const (
+	_streamDelay   = time.Second
+	_streamPreview = 1 << 20
+)
+
+// showStream shows the output of a long-running evaluation so far, in
+// place of the last result.
+func (m *model) showStream() {
+	if m.stream == nil || m.showOriginal || time.Since(m.evalStart) < _streamDelay {
+		return
+	}
+	n := m.stream.Len()
+	if n == m.streamShown {
+		return
+	}
+	if m.streamShown < _streamPreview {
+		text := m.stream.Text()
+		// jq prints JSON uncolored for engine.ColorJSON, which runs once
+		// it is done.
+		if !m.evalOptions.RawOutput() {
+			text = engine.ColorJSON(text)
+		}
+		m.layout(m.drawable(text))
+	}
+	m.streamShown = n
+	m.setStatus(nil, "%s so far…", input.FormatBytes(n))
+}
+
+// endStream puts the last result back in place of streamed output.
+func (m *model) endStream() {
+	if m.streamShown > 0 {
+		m.setStatus(nil, "")
+		m.refreshContent()
+	}
+	m.stream, m.streamShown = nil, 0
+}
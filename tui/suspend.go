@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"io"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// suspendMsg asks to suspend ijq, on a SIGTSTP sent to it from outside.
+type suspendMsg struct{}
+
+// resumeMsg reports that ijq was brought back to the foreground.
+type resumeMsg struct{ err error }
+
+// stopper is run in place of a process by tea.Exec, so that ijq stops
+// with the terminal released as it is for the editor, and takes it back
+// once continued.
+type stopper struct{}
+
+func (stopper) Run() error          { return stopProcess() }
+func (stopper) SetStdin(io.Reader)  {}
+func (stopper) SetStdout(io.Writer) {}
+func (stopper) SetStderr(io.Writer) {}
+
+// suspend leaves the alt screen and restores the terminal, then stops ijq
+// as a shell's job control expects; fg brings the screen back as it was.
+func (m *model) suspend() tea.Cmd {
+	return tea.Exec(stopper{}, func(err error) tea.Msg {
+		return resumeMsg{err: err}
+	})
+}
+
+// resumed reports a failed suspend and takes the mouse back, which
+// releasing the terminal gave up.
+func (m *model) resumed(msg resumeMsg) tea.Cmd {
+	if msg.err != nil {
+		m.setStatus(msg.err, "")
+	}
+	return m.restoreMouse()
+}
+
+// restoreMouse enables the mouse again after the terminal was handed to
+// another process, unless it was left to the terminal with --no-mouse.
+func (m model) restoreMouse() tea.Cmd {
+	if !m.mouse {
+		return nil
+	}
+	return tea.EnableMouseCellMotion
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stopProcess stops ijq's process group, as the terminal would on ctrl+z
+// outside raw mode, and returns once it is continued. It stops with
+// SIGSTOP: once notified of, SIGTSTP is handled by the Go runtime for
+// good and can no longer stop the process.
+func stopProcess() error {
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+	if err := syscall.Kill(0, syscall.SIGSTOP); err != nil {
+		return err
+	}
+	<-cont
+	return nil
+}
+
+// suspendTarget is the program a SIGTSTP suspends, if one is running.
+var suspendTarget struct {
+	sync.Mutex
+	once sync.Once
+	p    *tea.Program
+}
+
+// notifySuspend has p suspend ijq properly on a SIGTSTP, which would
+// otherwise stop it with the terminal still in raw mode and the alt
+// screen, until the returned func is called.
+//
+// signal.Stop would leave SIGTSTP ignored rather than restore its default
+// action, so that a program embedding the interface could no longer be
+// stopped once Run returns. Instead the signal stays notified for the life
+// of the process, and stops it as the default action would while no
+// program is running.
+func notifySuspend(p *tea.Program) (stop func()) {
+	suspendTarget.once.Do(func() {
+		tstp := make(chan os.Signal, 1)
+		signal.Notify(tstp, syscall.SIGTSTP)
+		go func() {
+			for range tstp {
+				suspendTarget.Lock()
+				p := suspendTarget.p
+				suspendTarget.Unlock()
+				if p != nil {
+					p.Send(suspendMsg{})
+				} else {
+					syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+				}
+			}
+		}()
+	})
+	suspendTarget.Lock()
+	suspendTarget.p = p
+	suspendTarget.Unlock()
+	return func() {
+		suspendTarget.Lock()
+		if suspendTarget.p == p {
+			suspendTarget.p = nil
+		}
+		suspendTarget.Unlock()
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package tui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func stopProcess() error {
+	return errors.New("suspend is not supported on Windows")
+}
+
+func notifySuspend(*tea.Program) (stop func()) { return func() {} }
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/itchyny/gojq"
+)
+
+var (
+	_syntaxError = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	_syntaxToken = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Underline(true)
+)
+
+// validateFilter parses the filter as it is typed, without running it, so
+// that syntax errors show up at once.
+func (m *model) validateFilter() {
+	var perr *gojq.ParseError
+	_, err := gojq.Parse(cmp.Or(m.filterValue(), "."))
+	if !errors.As(err, &perr) {
+		perr = nil
+	}
+	if (perr == nil) != (m.syntaxErr == nil) {
+		m.syntaxErr = perr
+		m.resize()
+	}
+	m.syntaxErr = perr
+}
+
+// syntaxErrorRange returns the runes of the filter holding the token the
+// parser stopped at.
+func (m model) syntaxErrorRange() (int, int) {
+	v := m.filterValue()
+	end := min(m.syntaxErr.Offset, len(v))
+	start := max(end-len(m.syntaxErr.Token), 0)
+	return len([]rune(v[:start])), len([]rune(v[:end]))
+}
+
+// syntaxView points at the syntax error in the filter, or returns nothing
+// if the filter parses.
+func (m model) syntaxView() string {
+	if m.syntaxErr == nil {
+		return ""
+	}
+	v := m.filterValue()
+	offset := min(m.syntaxErr.Offset, len(v))
+	line := strings.Count(v[:offset], "\n")
+	col := ansi.StringWidth(v[strings.LastIndexByte(v[:offset], '\n')+1 : offset])
+	msg := m.syntaxErr.Error()
+	if m.multiline {
+		col += ansi.StringWidth(m.editor.Prompt)
+		msg = fmt.Sprintf("line %d: %s", line+1, msg)
+	} else {
+		col += ansi.StringWidth(m.textinput.Prompt) - m.filterScroll()
+	}
+	col = min(max(col-1, 0), max(m.width-1, 0))
+	return ansi.Truncate(_syntaxError.Render(strings.Repeat(" ", col)+"^ "+msg), m.width, "…")
+}
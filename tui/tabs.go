@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/maolonglong/ijq/engine"
+)
+
+var (
+	_tab       = lipgloss.NewStyle().Faint(true).Padding(0, 1)
+	_activeTab = lipgloss.NewStyle().Reverse(true).Padding(0, 1)
+)
+
+// tab is the state of a filter tab while another one is shown. The model
+// holds the state of the active tab itself.
+type tab struct {
+	filter       string
+	evalOptions  engine.Options
+	result       string
+	resultFilter string
+	resultBytes  int
+	resultLines  int
+	prevResult   string
+	errText      string
+	evaluated    string
+	exitCode     int
+	evalTime     time.Duration
+	xOffset      int
+	yOffset      int
+	content      string
+	records      int
+	stages       []stage
+	results      resultHistory
+}
+
+// saveTab stores the state of the active tab.
+func (m *model) saveTab() {
+	if len(m.tabs) == 0 {
+		m.tabs = make([]tab, 1)
+	}
+	m.tabs[m.activeTab] = m.snapshot()
+	m.tabs[m.activeTab].results = m.results
+}
+
+// snapshot returns the state of the filter and its result.
+func (m model) snapshot() tab {
+	return tab{
+		filter:       m.filterValue(),
+		evalOptions:  m.evalOptions,
+		result:       m.result,
+		resultFilter: m.resultFilter,
+		resultBytes:  m.resultBytes,
+		resultLines:  m.resultLines,
+		prevResult:   m.prevResult,
+		errText:      m.errText,
+		evaluated:    m.evaluated,
+		exitCode:     m.exitCode,
+		evalTime:     m.evalTime,
+		xOffset:      m.xOffset,
+		yOffset:      m.viewport.YOffset,
+		content:      m.content,
+		records:      m.records,
+		stages:       m.stages,
+	}
+}
+
+// loadTab makes tab i the active one, evaluating its filter unless its
+// result is up to date.
+func (m *model) loadTab(i int) tea.Cmd {
+	m.stopEval()
+	m.activeTab = i
+	m.restore(m.tabs[i])
+	m.results = m.tabs[i].results
+	m.setStatus(nil, "tab %d of %d", i+1, len(m.tabs))
+	if m.upToDate() {
+		return nil
+	}
+	return m.startEval()
+}
+
+// restore puts back the state of the filter and its result saved by
+// snapshot.
+func (m *model) restore(t tab) {
+	m.setFilterValue(t.filter)
+	m.evalOptions = t.evalOptions
+	m.result = t.result
+	m.updateYAML()
+	m.updateGron()
+	m.updateElements()
+	m.resultFilter = t.resultFilter
+	m.resultBytes = t.resultBytes
+	m.resultLines = t.resultLines
+	m.prevResult = t.prevResult
+	m.errText = t.errText
+	m.evaluated = t.evaluated
+	m.exitCode = t.exitCode
+	m.evalTime = t.evalTime
+	if t.content != m.content {
+		m.content = t.content
+		m.keyIndex = nil
+		m.records = t.records
+		m.original, m.showOriginal = "", false
+		m.refreshSource()
+	}
+	m.stages = t.stages
+	m.updateDiff()
+	m.resetView()
+	m.xOffset = t.xOffset
+	m.refreshContent()
+	m.viewport.SetYOffset(t.yOffset)
+}
+
+// newTab opens a tab with an empty filter and the toggles of the current
+// one.
+func (m *model) newTab() tea.Cmd {
+	m.saveTab()
+	m.tabs = append(m.tabs, tab{evalOptions: m.evalOptions, content: m.content, stages: m.stages})
+	return m.loadTab(len(m.tabs) - 1)
+}
+
+// switchTab moves delta tabs to the right, wrapping around.
+func (m *model) switchTab(delta int) tea.Cmd {
+	if len(m.tabs) < 2 {
+		return nil
+	}
+	m.saveTab()
+	return m.loadTab((m.activeTab + delta + len(m.tabs)) % len(m.tabs))
+}
+
+// tabsView renders the tab bar, or nothing while there is a single tab.
+func (m model) tabsView() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	var tabs []string
+	for i, t := range m.tabs {
+		filter := t.filter
+		if i == m.activeTab {
+			filter = m.filterValue()
+		}
+		filter = cmp.Or(strings.TrimSpace(filter), ".")
+		label := fmt.Sprintf("%d: %s", i+1, truncate(strings.ReplaceAll(filter, "\n", " "), 20))
+		if i == m.activeTab {
+			tabs = append(tabs, _activeTab.Render(label))
+		} else {
+			tabs = append(tabs, _tab.Render(label))
+		}
+	}
+	return ansi.Truncate(strings.Join(tabs, ""), m.width, "…")
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/engine"
+)
+
+// Theme colors the interface and the JSON result. A color is an ANSI color
+// number, such as "4", or a hex color, such as "#5f87ff"; an empty one
+// keeps the default look. json holds the JSON colors in the format of
+// JQ_COLORS, such as "1;30:0;39:0;39:0;39:0;32:1;39:1;39:34;1".
+type Theme struct {
+	input  string
+	help   string
+	border string
+	status string
+	json   string
+}
+
+// ThemeDefault is the theme that keeps the terminal's own colors.
+const ThemeDefault = "default"
+
+// Themes are the built-in themes, which the [theme] table of the config
+// file can adjust.
+var Themes = map[string]Theme{
+	ThemeDefault: {},
+	"dark": {
+		input:  "12",
+		help:   "246",
+		border: "240",
+		status: "246",
+		json:   "1;90:0;33:0;33:0;36:0;32:1;37:1;37:1;34",
+	},
+	"light": {
+		input:  "4",
+		help:   "243",
+		border: "250",
+		status: "241",
+		json:   "0;90:0;35:0;35:0;34:0;32:1;30:1;30:1;34",
+	},
+}
+
+// ThemeNames are the names of the built-in themes, in the order --theme
+// lists them.
+var ThemeNames = []string{ThemeDefault, "dark", "light"}
+
+var (
+	// _prompt styles the prompt of the filter.
+	_prompt = lipgloss.NewStyle()
+	// _helpStyles style the key help.
+	_helpStyles = help.New().Styles
+)
+
+// newHelp returns the key help in the colors of the theme.
+func newHelp() help.Model {
+	h := help.New()
+	h.Styles = _helpStyles
+	return h
+}
+
+// Set changes the color of one part of t, named as in the [theme] table.
+func (t *Theme) Set(part, color string) error {
+	switch part {
+	case "input":
+		t.input = color
+	case "help":
+		t.help = color
+	case "border":
+		t.border = color
+	case "status":
+		t.status = color
+	case "json":
+		if !engine.JQColorRe.MatchString(color) {
+			return fmt.Errorf("theme: json: invalid colors %q, want the format of JQ_COLORS", color)
+		}
+		t.json = color
+	default:
+		return fmt.Errorf("theme: unknown part %q", part)
+	}
+	return nil
+}
+
+// Apply sets the styles of the interface and the JSON colors to t. The
+// JSON colors are otherwise taken from JQ_COLORS in jqColors, if set.
+func (t Theme) Apply(jqColors string) {
+	if t.input != "" {
+		_prompt = _prompt.Foreground(lipgloss.Color(t.input))
+	}
+	if t.help != "" {
+		c := lipgloss.Color(t.help)
+		_helpStyles.ShortKey = _helpStyles.ShortKey.Foreground(c).Bold(true)
+		_helpStyles.ShortDesc = _helpStyles.ShortDesc.Foreground(c)
+		_helpStyles.ShortSeparator = _helpStyles.ShortSeparator.Foreground(c)
+		_helpStyles.FullKey = _helpStyles.FullKey.Foreground(c).Bold(true)
+		_helpStyles.FullDesc = _helpStyles.FullDesc.Foreground(c)
+		_helpStyles.FullSeparator = _helpStyles.FullSeparator.Foreground(c)
+		_helpStyles.Ellipsis = _helpStyles.Ellipsis.Foreground(c)
+	}
+	if t.border != "" {
+		_gutter = _gutter.UnsetFaint().Foreground(lipgloss.Color(t.border))
+	}
+	if t.status != "" {
+		_statusInfo = _statusInfo.UnsetFaint().Foreground(lipgloss.Color(t.status))
+	}
+	if t.json != "" {
+		jqColors = t.json
+	}
+	// jq warns about invalid JQ_COLORS itself.
+	if engine.JQColorRe.MatchString(jqColors) {
+		engine.SetJSONColors(jqColors)
+	}
+}
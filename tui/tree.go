@@ -0,0 +1,381 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/itchyny/gojq"
+	"github.com/maolonglong/ijq/engine"
+)
+
+var _treeCursor = lipgloss.NewStyle().Bold(true)
+
+// treeNode is one JSON value of the result. Containers keep their members
+// in the order they were printed.
+type treeNode struct {
+	key       string
+	hasKey    bool
+	path      []any
+	value     any
+	container bool
+	object    bool
+	last      bool
+	children  []*treeNode
+}
+
+// treeLine is a line of the rendered tree: a node, or the closing bracket
+// of an expanded container.
+type treeLine struct {
+	node  *treeNode
+	close bool
+}
+
+// tree shows the result as a tree of foldable objects and arrays. Fold
+// state is keyed by path, so it survives evaluating the filter again.
+type tree struct {
+	roots     []*treeNode
+	collapsed map[string]bool
+	lines     []treeLine
+	cursor    int
+}
+
+// newTree parses the JSON values printed by jq. The fold state and cursor
+// of prev, if any, carry over.
+func newTree(result string, prev *tree) (*tree, error) {
+	t := &tree{collapsed: map[string]bool{}}
+	if prev != nil {
+		t.collapsed = prev.collapsed
+		t.cursor = prev.cursor
+	}
+	dec := json.NewDecoder(strings.NewReader(ansi.Strip(result)))
+	dec.UseNumber()
+	for {
+		n, err := parseTreeNode(dec, []any{len(t.roots)})
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("result is not JSON")
+		}
+		n.last = true
+		t.roots = append(t.roots, n)
+	}
+	t.layout()
+	return t, nil
+}
+
+func parseTreeNode(dec *json.Decoder, path []any) (*treeNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return &treeNode{path: path, value: tok}, nil
+	}
+	n := &treeNode{path: path, container: true, object: d == '{'}
+	for dec.More() {
+		var child *treeNode
+		if n.object {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k, _ := tok.(string)
+			if child, err = parseTreeNode(dec, append(path[:len(path):len(path)], k)); err != nil {
+				return nil, err
+			}
+			child.key, child.hasKey = k, true
+		} else {
+			if child, err = parseTreeNode(dec, append(path[:len(path):len(path)], len(n.children))); err != nil {
+				return nil, err
+			}
+		}
+		n.children = append(n.children, child)
+	}
+	if len(n.children) > 0 {
+		n.children[len(n.children)-1].last = true
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// id identifies n across evaluations: the index of its document followed
+// by its jq path.
+func (n *treeNode) id() string {
+	return fmt.Sprint(n.path[0]) + jqPath(n.path[1:])
+}
+
+// jqPath formats path as a jq path expression such as .a[0]["b c"].
+func jqPath(path []any) string {
+	if len(path) == 0 {
+		return "."
+	}
+	var sb strings.Builder
+	for _, p := range path {
+		switch p := p.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", p)
+		case string:
+			if isIdent(p) {
+				sb.WriteString("." + p)
+			} else {
+				b, _ := gojq.Marshal(p)
+				sb.WriteString("[" + string(b) + "]")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// layout lists the lines of every node not hidden in a collapsed container.
+func (t *tree) layout() {
+	t.lines = t.lines[:0]
+	var walk func(n *treeNode)
+	walk = func(n *treeNode) {
+		t.lines = append(t.lines, treeLine{node: n})
+		if !n.container || len(n.children) == 0 || t.collapsed[n.id()] {
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		t.lines = append(t.lines, treeLine{node: n, close: true})
+	}
+	for _, n := range t.roots {
+		walk(n)
+	}
+	t.cursor = max(min(t.cursor, len(t.lines)-1), 0)
+}
+
+// node returns the node under the cursor, or nil for an empty result.
+func (t *tree) node() *treeNode {
+	if len(t.lines) == 0 {
+		return nil
+	}
+	return t.lines[t.cursor].node
+}
+
+// toggle folds or unfolds the container under the cursor, moving the
+// cursor to its first line.
+func (t *tree) toggle() {
+	n := t.node()
+	if n == nil || !n.container || len(n.children) == 0 {
+		return
+	}
+	t.collapsed[n.id()] = !t.collapsed[n.id()]
+	t.layout()
+	for i, l := range t.lines {
+		if l.node == n {
+			t.cursor = i
+			break
+		}
+	}
+}
+
+// expandAll unfolds every container.
+func (t *tree) expandAll() {
+	n := t.node()
+	clear(t.collapsed)
+	t.layout()
+	t.moveTo(n)
+}
+
+// collapseAll folds every container below the top-level documents.
+func (t *tree) collapseAll() {
+	n := t.node()
+	var walk func(n *treeNode)
+	walk = func(n *treeNode) {
+		for _, c := range n.children {
+			if c.container && len(c.children) > 0 {
+				t.collapsed[c.id()] = true
+				walk(c)
+			}
+		}
+	}
+	for _, r := range t.roots {
+		walk(r)
+	}
+	t.layout()
+	// The node under the cursor may now be hidden, so fall back to its
+	// closest visible ancestor.
+	for n != nil && !t.moveTo(n) {
+		n = t.parent(n)
+	}
+}
+
+// moveTo puts the cursor on the opening line of n, reporting whether n is
+// visible.
+func (t *tree) moveTo(n *treeNode) bool {
+	for i, l := range t.lines {
+		if l.node == n && !l.close {
+			t.cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tree) parent(n *treeNode) *treeNode {
+	if len(n.path) <= 1 {
+		return nil
+	}
+	p := t.roots[n.path[0].(int)]
+	for _, k := range n.path[1 : len(n.path)-1] {
+		switch k := k.(type) {
+		case int:
+			p = p.children[k]
+		case string:
+			for _, c := range p.children {
+				if c.key == k {
+					p = c
+					break
+				}
+			}
+		}
+	}
+	return p
+}
+
+// render prints the visible lines in jq's colors, marking the cursor and
+// showing the number of members of collapsed containers.
+func (t *tree) render() string {
+	var sb strings.Builder
+	for i, l := range t.lines {
+		if i == t.cursor {
+			sb.WriteString(_treeCursor.Render("›") + " ")
+		} else {
+			sb.WriteString("  ")
+		}
+		n := l.node
+		sb.WriteString(strings.Repeat("  ", len(n.path)-1))
+		open, close, color := "[", "]", engine.ColorArray
+		if n.object {
+			open, close, color = "{", "}", engine.ColorObject
+		}
+		switch {
+		case l.close:
+			engine.Colorize(&sb, color, close)
+		case n.hasKey:
+			b, _ := gojq.Marshal(n.key)
+			engine.Colorize(&sb, engine.ColorKey, string(b))
+			engine.Colorize(&sb, engine.ColorObject, ":")
+			sb.WriteByte(' ')
+		}
+		if !l.close {
+			switch {
+			case !n.container:
+				engine.NewColorEncoder(&sb, engine.Options{}).Encode(n.value, 0)
+			case len(n.children) == 0:
+				engine.Colorize(&sb, color, open+close)
+			case t.collapsed[n.id()]:
+				engine.Colorize(&sb, color, open+"…"+close)
+			default:
+				engine.Colorize(&sb, color, open)
+			}
+		}
+		if !n.last && (l.close || !n.container || len(n.children) == 0 || t.collapsed[n.id()]) {
+			engine.Colorize(&sb, engine.ColorObject, ",")
+		}
+		if !l.close && n.container && len(n.children) > 0 && t.collapsed[n.id()] {
+			sb.WriteString(_statusInfo.Render(" " + countLabel(n)))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func countLabel(n *treeNode) string {
+	unit := "item"
+	if n.object {
+		unit = "key"
+	}
+	if len(n.children) != 1 {
+		unit += "s"
+	}
+	return strconv.Itoa(len(n.children)) + " " + unit
+}
+
+// toggleTree switches the viewport between the result as printed and its
+// tree.
+func (m *model) toggleTree() {
+	if m.tree != nil {
+		m.tree = nil
+		m.setStatus(nil, "tree view off")
+	} else {
+		t, err := newTree(m.shownText(), nil)
+		if err != nil {
+			m.setStatus(err, "")
+			return
+		}
+		m.tree = t
+		m.setStatus(nil, "tree view on")
+	}
+	m.keys.tree = m.tree != nil
+	m.search.find(m.viewText())
+	m.refreshContent()
+	m.viewport.GotoTop()
+}
+
+// updateTree handles the keys that move the cursor and fold containers in
+// the tree view, reporting whether msg was one of them.
+func (m *model) updateTree(msg tea.KeyMsg) bool {
+	t := m.tree
+	switch {
+	case key.Matches(msg, m.keys.viewport.Up):
+		t.cursor = max(t.cursor-1, 0)
+	case key.Matches(msg, m.keys.viewport.Down):
+		t.cursor = min(t.cursor+1, max(len(t.lines)-1, 0))
+	case key.Matches(msg, m.keys.viewport.PageUp):
+		t.cursor = max(t.cursor-m.viewport.Height, 0)
+	case key.Matches(msg, m.keys.viewport.PageDown):
+		t.cursor = min(t.cursor+m.viewport.Height, max(len(t.lines)-1, 0))
+	case key.Matches(msg, m.keys.gotoTop):
+		t.cursor = 0
+	case key.Matches(msg, m.keys.gotoBottom):
+		t.cursor = max(len(t.lines)-1, 0)
+	case key.Matches(msg, m.keys.toggleFold):
+		t.toggle()
+		m.search.find(m.viewText())
+	case key.Matches(msg, m.keys.expandAll):
+		t.expandAll()
+		m.search.find(m.viewText())
+	case key.Matches(msg, m.keys.collapseAll):
+		t.collapseAll()
+		m.search.find(m.viewText())
+	default:
+		return false
+	}
+	m.refreshContent()
+	m.showRow(m.rowOf(t.cursor))
+	return true
+}
+
+// showRow scrolls the viewport just enough to show row.
+func (m *model) showRow(row int) {
+	switch {
+	case row < m.viewport.YOffset:
+		m.viewport.SetYOffset(row)
+	case row >= m.viewport.YOffset+m.viewport.Height:
+		m.viewport.SetYOffset(row - m.viewport.Height + 1)
+	}
+}
@@ -0,0 +1,203 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/maolonglong/ijq/engine"
+)
+
+type varsKeyMap struct {
+	up     key.Binding
+	down   key.Binding
+	edit   key.Binding
+	add    key.Binding
+	remove key.Binding
+	close  key.Binding
+}
+
+func (k varsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.close, k.edit, k.add, k.remove, k.up, k.down}
+}
+
+func (k varsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+type editKeyMap struct {
+	save   key.Binding
+	cancel key.Binding
+}
+
+func (k editKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.save, k.cancel}
+}
+
+func (k editKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// varsPanel is an overlay for adding, editing, and removing the variables
+// bound with --arg, --argjson, --rawfile and --slurpfile.
+type varsPanel struct {
+	vars     []engine.Variable
+	cursor   int
+	editing  bool
+	changed  bool
+	err      error
+	input    textinput.Model
+	keys     varsKeyMap
+	editKeys editKeyMap
+}
+
+func newVarsPanel(vars []engine.Variable) *varsPanel {
+	ti := textinput.New()
+	ti.Prompt = "$"
+	ti.Placeholder = "name=string, name:=json or name@=file"
+	return &varsPanel{
+		vars:  append([]engine.Variable(nil), vars...),
+		input: ti,
+		keys: varsKeyMap{
+			up: key.NewBinding(
+				key.WithKeys("up", "k"),
+				key.WithHelp("↑/k", "up"),
+			),
+			down: key.NewBinding(
+				key.WithKeys("down", "j"),
+				key.WithHelp("↓/j", "down"),
+			),
+			edit: key.NewBinding(
+				key.WithKeys("enter", "e"),
+				key.WithHelp("enter", "edit"),
+			),
+			add: key.NewBinding(
+				key.WithKeys("a", "ctrl+n"),
+				key.WithHelp("a", "add"),
+			),
+			remove: key.NewBinding(
+				key.WithKeys("d", "delete"),
+				key.WithHelp("d", "delete"),
+			),
+			close: key.NewBinding(
+				key.WithKeys("esc", "ctrl+v", "ctrl+c"),
+				key.WithHelp("esc", "close"),
+			),
+		},
+		editKeys: editKeyMap{
+			save: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "save"),
+			),
+			cancel: key.NewBinding(
+				key.WithKeys("esc", "ctrl+c"),
+				key.WithHelp("esc", "cancel"),
+			),
+		},
+	}
+}
+
+func (p *varsPanel) keyMap() help.KeyMap {
+	if p.editing {
+		return p.editKeys
+	}
+	return p.keys
+}
+
+func (p *varsPanel) update(m *model, msg tea.KeyMsg) (bool, tea.Cmd) {
+	if p.editing {
+		return false, p.updateEdit(msg)
+	}
+	switch {
+	case key.Matches(msg, p.keys.close):
+		if !p.changed {
+			return true, nil
+		}
+		m.evalOptions.Vars = p.vars
+		return true, m.startEval()
+	case key.Matches(msg, p.keys.up):
+		p.cursor = max(p.cursor-1, 0)
+	case key.Matches(msg, p.keys.down):
+		p.cursor = min(p.cursor+1, max(len(p.vars)-1, 0))
+	case key.Matches(msg, p.keys.add):
+		p.cursor = len(p.vars)
+		return false, p.startEdit("")
+	case key.Matches(msg, p.keys.edit):
+		if p.cursor < len(p.vars) {
+			return false, p.startEdit(p.vars[p.cursor].String())
+		}
+		return false, p.startEdit("")
+	case key.Matches(msg, p.keys.remove):
+		if p.cursor < len(p.vars) {
+			p.vars = append(p.vars[:p.cursor], p.vars[p.cursor+1:]...)
+			p.cursor = max(min(p.cursor, len(p.vars)-1), 0)
+			p.changed = true
+		}
+	}
+	return false, nil
+}
+
+func (p *varsPanel) startEdit(s string) tea.Cmd {
+	p.editing = true
+	p.err = nil
+	p.input.SetValue(s)
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *varsPanel) updateEdit(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, p.editKeys.cancel):
+		p.editing = false
+		p.input.Blur()
+		return nil
+	case key.Matches(msg, p.editKeys.save):
+		v, err := engine.ParseVariable(p.input.Value())
+		if err != nil {
+			p.err = err
+			return nil
+		}
+		if p.cursor < len(p.vars) {
+			p.vars[p.cursor] = v
+		} else {
+			p.vars = append(p.vars, v)
+		}
+		p.changed = true
+		p.editing = false
+		p.input.Blur()
+		return nil
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+func (p *varsPanel) view(width, height int) string {
+	lines := []string{_pickerTitle.Render("variables")}
+	if len(p.vars) == 0 && !p.editing {
+		lines = append(lines, "  no variables, press a to add one")
+	}
+	for i, v := range p.vars {
+		if p.editing && i == p.cursor {
+			lines = append(lines, p.input.View())
+			continue
+		}
+		line := "$" + v.String()
+		if i == p.cursor {
+			line = _pickerSelected.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if p.editing && p.cursor == len(p.vars) {
+		lines = append(lines, p.input.View())
+	}
+	if p.err != nil {
+		lines = append(lines, _errorText.Render(p.err.Error()))
+	}
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// _watchInterval is how often --watch checks the input files for changes
+// when the file system cannot report them.
+const _watchInterval = 500 * time.Millisecond
+
+// Watcher reloads the input when one of the input files changes. It is
+// told of changes by the file system, and polls the files where that is
+// not available. Without files, as for --exec, it reloads on every tick.
+type Watcher struct {
+	files  []string
+	stamps []fileStamp
+	// events reports changes in the directories of the files, or is nil
+	// if they are polled. names are the files as events name them.
+	events *fsnotify.Watcher
+	names  map[string]bool
+	// interval is the time between ticks, or 0 to reload only on request.
+	interval time.Duration
+	// load reads and converts the input files again, as a single document
+	// or one for each file.
+	load func() ([]input.Document, error)
+	// loading is set while load runs, and again if a file changed
+	// meanwhile.
+	loading bool
+	again   bool
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type (
+	watchTickMsg     struct{}
+	inputReloadedMsg struct {
+		documents []input.Document
+		err       error
+	}
+)
+
+// NewWatcher returns a watcher of files that runs load when one of them
+// changes. It watches the directories of the files rather than the files,
+// so that it follows files that editors replace by a rename.
+func NewWatcher(files []string, load func() ([]input.Document, error)) *Watcher {
+	w := &Watcher{files: files, load: load}
+	w.stamps = w.stat()
+	if err := w.watch(); err != nil {
+		w.Close()
+		w.events, w.interval = nil, _watchInterval
+	}
+	return w
+}
+
+func (w *Watcher) watch() error {
+	var err error
+	if w.events, err = fsnotify.NewWatcher(); err != nil {
+		return err
+	}
+	w.names = map[string]bool{}
+	for _, name := range w.files {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return err
+		}
+		w.names[abs] = true
+		if err := w.events.Add(filepath.Dir(abs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops watching the files.
+func (w *Watcher) Close() error {
+	if w == nil || w.events == nil {
+		return nil
+	}
+	return w.events.Close()
+}
+
+// NewCommandWatcher returns a watcher that runs load every interval.
+func NewCommandWatcher(interval time.Duration, load func() ([]input.Document, error)) *Watcher {
+	return &Watcher{interval: interval, load: load}
+}
+
+func (w *Watcher) stat() []fileStamp {
+	stamps := make([]fileStamp, len(w.files))
+	for i, name := range w.files {
+		if fi, err := os.Stat(name); err == nil {
+			stamps[i] = fileStamp{fi.ModTime(), fi.Size()}
+		}
+	}
+	return stamps
+}
+
+// changed reports whether a file changed since the last call.
+func (w *Watcher) changed() bool {
+	if len(w.files) == 0 {
+		return true
+	}
+	stamps := w.stat()
+	if slices.Equal(stamps, w.stamps) {
+		return false
+	}
+	w.stamps = stamps
+	return true
+}
+
+// tick waits for the files to be checked again: for an event on one of
+// them, or the next tick when polling.
+func (w *Watcher) tick() tea.Cmd {
+	if w.events != nil {
+		events := w.events
+		return func() tea.Msg {
+			for {
+				select {
+				case ev, ok := <-events.Events:
+					if !ok {
+						return nil
+					}
+					if w.names[ev.Name] && ev.Op != fsnotify.Chmod {
+						return watchTickMsg{}
+					}
+				case _, ok := <-events.Errors:
+					// Events may have been lost, so check the files.
+					if !ok {
+						return nil
+					}
+					return watchTickMsg{}
+				}
+			}
+		}
+	}
+	if w.interval == 0 {
+		return nil
+	}
+	return tea.Tick(w.interval, func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+// reload loads the input again, or once more after the load running
+// already.
+func (w *Watcher) reload() tea.Cmd {
+	if w.loading {
+		w.again = true
+		return nil
+	}
+	w.loading = true
+	return func() tea.Msg {
+		docs, err := w.load()
+		return inputReloadedMsg{documents: docs, err: err}
+	}
+}
+
+// inputReloaded replaces the input with the reloaded files and evaluates
+// the filter again, keeping the scroll position. Tabs on the same input
+// are updated too, and with several documents the active one is shown.
+// While the input is the result of pipeline stages, the
+// new input is only taken on reset.
+func (m *model) inputReloaded(msg inputReloadedMsg) tea.Cmd {
+	m.watcher.loading = false
+	if m.watcher.again {
+		m.watcher.again = false
+		return m.watcher.reload()
+	}
+	if msg.err != nil {
+		m.setStatus(msg.err, "")
+		return nil
+	}
+	if len(m.documents) > 1 {
+		m.documents = msg.documents
+	}
+	content := msg.documents[min(m.activeDoc, len(msg.documents)-1)].Content
+	m.cache.clear()
+	records := input.CountRecords(content)
+	m.replaceRoot(content, records)
+	if len(m.stages) > 0 {
+		m.setStatus(nil, "input changed, alt+z to reset to it")
+		return nil
+	}
+	m.yOffset = m.viewport.YOffset
+	m.setContent(content, records)
+	m.setStatus(nil, "reloaded input")
+	return m.startEval()
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/maolonglong/ijq/internal/input"
+)
+
+// nextTick runs the command that waits for the watched files, failing the
+// test if it does not return within a few seconds.
+func nextTick(t *testing.T, cmd tea.Cmd) {
+	t.Helper()
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if _, ok := msg.(watchTickMsg); !ok {
+			t.Fatalf("watcher returned %#v, want a tick", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not report the change")
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.json")
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher([]string{path}, nil)
+	defer w.Close()
+	if w.events == nil {
+		t.Skip("file system events are not available")
+	}
+	if w.interval != 0 {
+		t.Errorf("watcher with events polls every %v", w.interval)
+	}
+
+	// Other files in the directory are left alone.
+	cmd := w.tick()
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("[1, 2]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nextTick(t, cmd)
+	if !w.changed() {
+		t.Error("changed() = false after a write")
+	}
+
+	// A file replaced by a rename is still watched.
+	tmp := filepath.Join(dir, ".in.json.tmp")
+	if err := os.WriteFile(tmp, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = w.tick()
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	nextTick(t, cmd)
+	if !w.changed() {
+		t.Error("changed() = false after a rename")
+	}
+	if w.changed() {
+		t.Error("changed() = true with no change since the last call")
+	}
+}
+
+func TestWatcherPolling(t *testing.T) {
+	w := NewWatcher([]string{filepath.Join(t.TempDir(), "missing", "in.json")}, nil)
+	defer w.Close()
+	if w.events != nil || w.interval != _watchInterval {
+		t.Errorf("watcher of a file in a missing directory has events %v and interval %v, want polling", w.events, w.interval)
+	}
+}
+
+func TestWatcherReloadAgain(t *testing.T) {
+	loads := 0
+	w := NewCommandWatcher(0, func() ([]input.Document, error) {
+		loads++
+		return []input.Document{{Content: "1"}}, nil
+	})
+	cmd := w.reload()
+	if w.reload() != nil || !w.again {
+		t.Fatal("reload during a load did not wait for it to end")
+	}
+	cmd()
+	m := model{watcher: w}
+	if m.inputReloaded(inputReloadedMsg{}) == nil || w.again {
+		t.Error("a change during the load did not load the input once more")
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times before the second load ran, want 1", loads)
+	}
+}